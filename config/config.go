@@ -1,6 +1,66 @@
 package config
 
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
 type Decoder interface {
 	// Decode 加载配置文件
 	Decode(v interface{}) error
 }
+
+// RawDecoder 可仅解码的 Decoder，Loader 按来源合并配置时使用
+type RawDecoder interface {
+	Decoder
+	// DecodeRaw 仅解码配置，不填充默认值、不使用环境变量覆盖、不展开引用、不校验
+	// 返回配置中设置的字段路径(与 Loader.Source 的 key 相同)，如: database.addrs
+	DecodeRaw(v interface{}) ([]string, error)
+}
+
+// FileDecoder 基于本地文件的 Decoder
+type FileDecoder interface {
+	RawDecoder
+	// FilePath 配置文件路径
+	FilePath() string
+}
+
+// StateDecoder 可检测配置变化的 Decoder，如: 远程配置
+type StateDecoder interface {
+	Decoder
+	// State 配置的版本标识(ETag、修改时间等)，变化时 Watch 重新加载
+	State() (string, error)
+}
+
+// NewDecoder Decoder 构造函数
+type NewDecoder func(opts ...Option) Decoder
+
+var (
+	mu       sync.RWMutex
+	decoders = map[string]NewDecoder{}
+)
+
+// Register 注册文件扩展名对应的 Decoder，由各 Decoder 包在 init 中调用
+func Register(ext string, fn NewDecoder) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	decoders[strings.ToLower(ext)] = fn
+}
+
+// NewDecoderFromPath 根据文件扩展名创建 Decoder
+// 需导入对应的 Decoder 包，如: import _ "github.com/charlesbases/hfw/config/yaml"
+func NewDecoderFromPath(fpath string, opts ...Option) (Decoder, error) {
+	ext := strings.ToLower(filepath.Ext(fpath))
+
+	mu.RLock()
+	fn, found := decoders[ext]
+	mu.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("config: unsupported file extension %q of %s", ext, fpath)
+	}
+	return fn(append(opts, FilePath(fpath))...), nil
+}
@@ -0,0 +1,171 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/charlesbases/hfw/config"
+	_ "github.com/charlesbases/hfw/config/dotenv"
+	_ "github.com/charlesbases/hfw/config/ini"
+	_ "github.com/charlesbases/hfw/config/json"
+	_ "github.com/charlesbases/hfw/config/toml"
+	_ "github.com/charlesbases/hfw/config/yaml"
+)
+
+type testConfiguration struct {
+	Name     string
+	Port     string
+	Database *testDatabase
+}
+
+type testDatabase struct {
+	Debug bool
+	Addrs []string
+
+	MaxIdleConns int `json:"max_idle_conns" yaml:"max_idle_conns" toml:"max_idle_conns"`
+}
+
+var files = map[string]string{
+	"config.json": `{"name": "app", "port": ":8080", "database": {"debug": true, "addrs": ["a", "b"], "max_idle_conns": 10}}`,
+	"config.yaml": "name: app\nport: ':8080'\ndatabase:\n  debug: true\n  addrs:\n  - a\n  - b\n  max_idle_conns: 10\n",
+	"config.toml": "name = 'app'\nport = ':8080'\n[database]\ndebug = true\naddrs = ['a', 'b']\nmax_idle_conns = 10\n",
+	"config.ini":  "; comment\nname = app\nport = :8080\n\n[database]\ndebug = true\naddrs = a,b\nmax_idle_conns = 10\n",
+	"config.env":  "# comment\nHFW_NAME=app\nexport HFW_PORT=\":8080\"\nHFW_DATABASE_DEBUG=true # comment\nHFW_DATABASE_ADDRS='a,b'\nHFW_DATABASE_MAX_IDLE_CONNS=10\n",
+}
+
+func TestNewDecoderFromPath(t *testing.T) {
+	var (
+		root   = t.TempDir()
+		expect = &testConfiguration{Name: "app", Port: ":8080", Database: &testDatabase{Debug: true, Addrs: []string{"a", "b"}, MaxIdleConns: 10}}
+	)
+
+	for name, data := range files {
+		fpath := filepath.Join(root, name)
+		if err := os.WriteFile(fpath, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		decoder, err := config.NewDecoderFromPath(fpath, config.EnvPrefix("HFW"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c = new(testConfiguration)
+		if err := decoder.Decode(c); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(c, expect) {
+			t.Fatalf("%s: unexpected configuration: %+v %+v", name, c, c.Database)
+		}
+	}
+
+	if _, err := config.NewDecoderFromPath("config.xml"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLoaderFiles(t *testing.T) {
+	var (
+		root = t.TempDir()
+		base = filepath.Join(root, "base.yaml")
+		prod = filepath.Join(root, "prod.json")
+	)
+	if err := os.WriteFile(base, []byte(files["config.yaml"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 显式设置零值
+	if err := os.WriteFile(prod, []byte(`{"port": "", "database": {"debug": false}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoders = make([]config.Decoder, 0, 2)
+	for _, fpath := range []string{base, prod} {
+		decoder, err := config.NewDecoderFromPath(fpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoders = append(decoders, decoder)
+	}
+
+	loader := config.NewLoader(config.LoadFiles(decoders...))
+	var c = new(testConfiguration)
+	if err := loader.Decode(c); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &testConfiguration{Name: "app", Database: &testDatabase{Addrs: []string{"a", "b"}, MaxIdleConns: 10}}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected configuration: %+v %+v", c, c.Database)
+	}
+	for key, source := range map[string]string{
+		"name":                    config.SourceFile + ":" + base,
+		"port":                    config.SourceFile + ":" + prod,
+		"database.debug":          config.SourceFile + ":" + prod,
+		"database.max_idle_conns": config.SourceFile + ":" + base,
+	} {
+		if s := loader.Source(key); s != source {
+			t.Fatalf("%s: expected source %s, got %s", key, source, s)
+		}
+	}
+}
+
+func TestDecoderDefaults(t *testing.T) {
+	type configuration struct {
+		Enable bool   `default:"true"`
+		Port   int    `default:"8080"`
+		Name   string `default:"app"`
+	}
+
+	var root = t.TempDir()
+	for name, data := range map[string]string{
+		"config.json": `{"enable": false, "port": 0}`,
+		"config.yaml": "enable: false\nport: 0\n",
+		"config.toml": "enable = false\nport = 0\n",
+		"config.ini":  "enable = false\nport = 0\n",
+		"config.env":  "ENABLE=false\nPORT=0\n",
+	} {
+		fpath := filepath.Join(root, name)
+		if err := os.WriteFile(fpath, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		decoder, err := config.NewDecoderFromPath(fpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c = new(configuration)
+		if err := decoder.Decode(c); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if c.Enable || c.Port != 0 || c.Name != "app" {
+			t.Fatalf("%s: unexpected configuration: %+v", name, c)
+		}
+	}
+}
+
+func TestINIValues(t *testing.T) {
+	type configuration struct {
+		A, B, C, D, E string
+	}
+
+	fpath := filepath.Join(t.TempDir(), "config.ini")
+	data := "a = \"'a'\"\nb = a\"\nc = x ; comment\nd = \"x ; y\"\ne = y # comment\n"
+	if err := os.WriteFile(fpath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := config.NewDecoderFromPath(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c = new(configuration)
+	if err := decoder.Decode(c); err != nil {
+		t.Fatal(err)
+	}
+	if expect := (configuration{A: "'a'", B: `a"`, C: "x", D: "x ; y", E: "y"}); *c != expect {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Defaults 使用 `default:"..."` 填充零值字段。Decoder 在解码前调用，由配置文件覆盖默认值
+func Defaults(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: defaults of non-pointer %T", v)
+	}
+	_, err := defaults(rv)
+	return err
+}
+
+// defaults 返回被默认值填充的字段
+func defaults(rv reflect.Value) ([]*field, error) {
+	var found = make([]*field, 0)
+	for _, f := range fieldsOf(rv.Type()) {
+		val, ok := f.Last().Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+
+		if fv := valueOf(rv, f.index, false); fv.IsValid() && !fv.IsZero() {
+			continue
+		}
+		if err := setValue(valueOf(rv, f.index, true), val); err != nil {
+			return found, fmt.Errorf("config: default %s: %v", f.Key(), err)
+		}
+		found = append(found, f)
+	}
+	return found, nil
+}
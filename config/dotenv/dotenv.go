@@ -0,0 +1,109 @@
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charlesbases/hfw/config"
+)
+
+// defaultConfigurationFilePath 默认配置文件路径
+const defaultConfigurationFilePath = ".env"
+
+// parser .
+// 文件格式为 KEY=VALUE，KEY 的命名规则与 config.Overlay 相同，前缀为 config.EnvPrefix
+type parser struct {
+	options *config.Options
+}
+
+func init() {
+	config.Register(".env", NewDecoder)
+}
+
+// NewDecoder .
+func NewDecoder(opts ...config.Option) config.Decoder {
+	var options = new(config.Options)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.FilePath) == 0 {
+		options.FilePath = defaultConfigurationFilePath
+	}
+
+	return &parser{options: options}
+}
+
+// Decode .
+func (p *parser) Decode(v interface{}) error {
+	if err := config.Defaults(v); err != nil {
+		return err
+	}
+	if _, err := p.DecodeRaw(v); err != nil {
+		return err
+	}
+	return p.options.Complete(v)
+}
+
+// DecodeRaw .
+func (p *parser) DecodeRaw(v interface{}) ([]string, error) {
+	vars, err := Read(p.options.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return config.OverlayMap(v, p.options.EnvPrefix, vars)
+}
+
+// FilePath .
+func (p *parser) FilePath() string {
+	return p.options.FilePath
+}
+
+// Read 读取 .env 文件
+func Read(fpath string) (map[string]string, error) {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var vars = make(map[string]string)
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		i := strings.Index(text, "=")
+		if i == -1 {
+			return nil, fmt.Errorf("dotenv: %s:%d: missing '='", fpath, line)
+		}
+
+		key, val := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+		if len(key) == 0 {
+			return nil, fmt.Errorf("dotenv: %s:%d: empty key", fpath, line)
+		}
+
+		switch {
+		case strings.HasPrefix(val, `"`):
+			if val, err = strconv.Unquote(val); err != nil {
+				return nil, fmt.Errorf("dotenv: %s:%d: %v", fpath, line, err)
+			}
+		case strings.HasPrefix(val, `'`) && strings.HasSuffix(val, `'`) && len(val) > 1:
+			val = val[1 : len(val)-1]
+		default:
+			// 行尾注释
+			if i := strings.Index(val, " #"); i != -1 {
+				val = strings.TrimSpace(val[:i])
+			}
+		}
+		vars[key] = val
+	}
+	return vars, scanner.Err()
+}
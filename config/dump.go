@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/charlesbases/hfw/codec"
+)
+
+// secretMask 敏感字段掩码
+const secretMask = "******"
+
+// Dump 使用 m 序列化配置，`secret:"true"` 的字段使用掩码替换
+// 字符串(及字符串切片、map 中的字符串)替换为掩码，其他类型置为零值
+func Dump(v interface{}, m codec.Marshaler) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return m.Marshal(v)
+	}
+
+	cv := reflect.New(rv.Type()).Elem()
+	redact(cv, rv, false)
+	return m.Marshal(cv.Interface())
+}
+
+// redact 深拷贝 src 到 dst，secret 为 true 时使用掩码替换
+func redact(dst, src reflect.Value, secret bool) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		nv := reflect.New(src.Type().Elem())
+		redact(nv.Elem(), src.Elem(), secret)
+		dst.Set(nv)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		nv := reflect.New(src.Elem().Type()).Elem()
+		redact(nv, src.Elem(), secret)
+		dst.Set(nv)
+	case reflect.Struct:
+		// 未导出字段整体复制，time.Time 等不展开
+		if secret {
+			dst.Set(reflect.Zero(dst.Type()))
+		} else {
+			dst.Set(src)
+		}
+		rt := src.Type()
+		if isLeaf(rt) {
+			return
+		}
+		for i := 0; i < rt.NumField(); i++ {
+			sf := rt.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			redact(dst.Field(i), src.Field(i), secret || sf.Tag.Get("secret") == "true")
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		nv := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			redact(nv.Index(i), src.Index(i), secret)
+		}
+		dst.Set(nv)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			redact(dst.Index(i), src.Index(i), secret)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		nv := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			ev := reflect.New(src.Type().Elem()).Elem()
+			redact(ev, iter.Value(), secret)
+			nv.SetMapIndex(iter.Key(), ev)
+		}
+		dst.Set(nv)
+	case reflect.String:
+		if secret && src.Len() != 0 {
+			dst.SetString(secretMask)
+		} else {
+			dst.Set(src)
+		}
+	default:
+		if secret {
+			dst.Set(reflect.Zero(dst.Type()))
+		} else {
+			dst.Set(src)
+		}
+	}
+}
+
+// Diff 返回 old 与 new 中值不同的字段路径，如: database.addrs
+// old 或 new 为 nil 时视为零值
+func Diff(old, new interface{}) ([]string, error) {
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	switch {
+	case !ov.IsValid() && !nv.IsValid():
+		return []string{}, nil
+	case !ov.IsValid():
+		ov = reflect.Zero(nv.Type())
+	case !nv.IsValid():
+		nv = reflect.Zero(ov.Type())
+	}
+	if ov.Type() != nv.Type() {
+		return nil, fmt.Errorf("config: diff of different types %T and %T", old, new)
+	}
+
+	var keys = make([]string, 0)
+	for _, f := range fieldsOf(ov.Type()) {
+		if !reflect.DeepEqual(leafOf(ov, f), leafOf(nv, f)) {
+			keys = append(keys, f.Key())
+		}
+	}
+	return keys, nil
+}
+
+// leafOf 字段值，所在结构体为 nil 时返回零值
+func leafOf(rv reflect.Value, f *field) interface{} {
+	if rv.Kind() != reflect.Ptr {
+		// 不可寻址时复制一份
+		pv := reflect.New(rv.Type())
+		pv.Elem().Set(rv)
+		rv = pv
+	}
+	if fv := valueOf(rv, f.index, false); fv.IsValid() {
+		return fv.Interface()
+	}
+	return reflect.Zero(f.Last().Type).Interface()
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/hfw/codec/json"
+)
+
+type testDumpConfiguration struct {
+	Name     string
+	Started  time.Time
+	JWT      *testDumpJwt
+	Database *testDumpDatabase
+}
+
+type testDumpJwt struct {
+	Enable bool
+	Secret string `secret:"true"`
+}
+
+type testDumpDatabase struct {
+	Addrs []string `secret:"true"`
+	Port  int      `secret:"true"`
+	Debug bool
+}
+
+func TestDump(t *testing.T) {
+	var c = &testDumpConfiguration{
+		Name:     "app",
+		Started:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		JWT:      &testDumpJwt{Enable: true, Secret: "jwt-secret"},
+		Database: &testDumpDatabase{Addrs: []string{"root:pass@tcp(127.0.0.1:3306)/app"}, Port: 3306, Debug: true},
+	}
+
+	data, err := Dump(c, json.DefaultMarshaler)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dump := string(data)
+	if strings.Contains(dump, "jwt-secret") || strings.Contains(dump, "pass") || strings.Contains(dump, "3306") {
+		t.Fatalf("secret leaked: %s", dump)
+	}
+	if !strings.Contains(dump, `"Name":"app"`) || !strings.Contains(dump, `"Debug":true`) || !strings.Contains(dump, `"Started":"2024-01-02T03:04:05Z"`) {
+		t.Fatalf("unexpected dump: %s", dump)
+	}
+	if c.JWT.Secret != "jwt-secret" || c.Database.Port != 3306 {
+		t.Fatal("original configuration modified")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var (
+		old = &testDumpConfiguration{Name: "app", JWT: &testDumpJwt{Secret: "a"}}
+		new = &testDumpConfiguration{Name: "app", Started: time.Now(), JWT: &testDumpJwt{Secret: "b"}, Database: &testDumpDatabase{Debug: true}}
+	)
+
+	keys, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"started", "jwt.secret", "database.debug"}) {
+		t.Fatalf("unexpected diff: %v", keys)
+	}
+
+	if keys, err := Diff(nil, new); err != nil || len(keys) != 4 {
+		t.Fatalf("unexpected diff: %v %v", keys, err)
+	}
+	if keys, err := Diff(nil, nil); err != nil || len(keys) != 0 {
+		t.Fatalf("unexpected diff: %v %v", keys, err)
+	}
+}
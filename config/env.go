@@ -0,0 +1,184 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envSeparator 环境变量路径分隔符
+const envSeparator = "_"
+
+// Overlay 使用环境变量覆盖配置
+// 环境变量名由 prefix 与字段路径组成，字段名转换为大写的 snake_case，如: HFW_DATABASE_MAX_IDLE_CONNS
+// 字段可通过 `env:"..."` 指定名称
+// 切片使用 ',' 分隔，time.Duration 支持 "3s" 与整数(秒)两种格式，time.Time 为 RFC3339 格式
+func Overlay(v interface{}, prefix string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: overlay of non-pointer %T", v)
+	}
+	_, err := overlay(rv, prefix, os.LookupEnv)
+	return err
+}
+
+// OverlayMap 使用 m 覆盖配置，key 的命名规则与 Overlay 相同。返回被覆盖的字段路径
+func OverlayMap(v interface{}, prefix string, m map[string]string) ([]string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, fmt.Errorf("config: overlay of non-pointer %T", v)
+	}
+	found, err := overlay(rv, prefix, func(key string) (string, bool) {
+		val, found := m[key]
+		return val, found
+	})
+	return keysOf(found), err
+}
+
+// OverlayKeys 使用 m 覆盖配置，key 为字段路径，不区分大小写。返回被覆盖的字段路径
+// 字段路径与 Loader 的字段路径相同(如: database.max_idle_conns 对应 `toml:"max_idle_conns"`)，或由 snake_case 字段名组成
+func OverlayKeys(v interface{}, m map[string]string) ([]string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, fmt.Errorf("config: overlay of non-pointer %T", v)
+	}
+
+	var keys = make(map[string]string, len(m))
+	for key, val := range m {
+		keys[strings.ToLower(key)] = val
+	}
+
+	var found = make([]*field, 0)
+	for _, f := range fieldsOf(rv.Type()) {
+		for _, key := range []string{strings.ToLower(f.Key()), f.SnakeKey()} {
+			val, ok := keys[key]
+			if !ok {
+				continue
+			}
+			if err := setValue(valueOf(rv, f.index, true), val); err != nil {
+				return keysOf(found), fmt.Errorf("config: %s: %v", key, err)
+			}
+			found = append(found, f)
+			break
+		}
+	}
+	return keysOf(found), nil
+}
+
+// overlay 返回被覆盖的字段
+func overlay(rv reflect.Value, prefix string, lookup func(key string) (string, bool)) ([]*field, error) {
+	var found = make([]*field, 0)
+	for _, f := range fieldsOf(rv.Type()) {
+		name, ok := envName(f, prefix)
+		if !ok {
+			continue
+		}
+
+		val, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(valueOf(rv, f.index, true), val); err != nil {
+			return found, fmt.Errorf("config: %s: %v", name, err)
+		}
+		found = append(found, f)
+	}
+	return found, nil
+}
+
+// envName 字段对应的环境变量名。字段标记为 `env:"-"` 时返回 false
+func envName(f *field, prefix string) (string, bool) {
+	var names = make([]string, 0, len(f.fields)+1)
+	if len(prefix) != 0 {
+		names = append(names, strings.ToUpper(prefix))
+	}
+	for _, sf := range f.fields {
+		tag := sf.Tag.Get("env")
+		switch tag {
+		case "-":
+			return "", false
+		case "":
+			names = append(names, strings.ToUpper(snakeCase(sf.Name)))
+		default:
+			names = append(names, tag)
+		}
+	}
+	return strings.Join(names, envSeparator), true
+}
+
+// setValue 将字符串解析为 rv 对应的类型
+func setValue(rv reflect.Value, val string) error {
+	if rv.Type() == durationType {
+		d, err := parseDuration(val)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(int64(d))
+		return nil
+	}
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(n)
+	case reflect.Ptr:
+		nv := reflect.New(rv.Type().Elem())
+		if err := setValue(nv.Elem(), val); err != nil {
+			return err
+		}
+		rv.Set(nv)
+	case reflect.Slice:
+		var items []string
+		if len(val) != 0 {
+			items = strings.Split(val, ",")
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+// parseDuration 支持 "3s" 与整数(秒)
+func parseDuration(val string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(val)
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+type testConfiguration struct {
+	Name     string
+	Port     int
+	Started  time.Time
+	Database *testDatabase
+}
+
+type testDatabase struct {
+	Enable  bool
+	Addrs   []string
+	Timeout time.Duration
+	Secret  string `env:"PASSWORD"`
+
+	MaxIdleConns int
+}
+
+func TestOverlay(t *testing.T) {
+	t.Setenv("HFW_NAME", "app")
+	t.Setenv("HFW_PORT", "8080")
+	t.Setenv("HFW_STARTED", "2024-01-02T03:04:05Z")
+	t.Setenv("HFW_DATABASE_ENABLE", "true")
+	t.Setenv("HFW_DATABASE_ADDRS", "a, b")
+	t.Setenv("HFW_DATABASE_TIMEOUT", "3")
+	t.Setenv("HFW_DATABASE_PASSWORD", "secret")
+	t.Setenv("HFW_DATABASE_MAX_IDLE_CONNS", "10")
+
+	var c = new(testConfiguration)
+	if err := Overlay(c, "hfw"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "app" || c.Port != 8080 || !c.Started.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+	if c.Database == nil {
+		t.Fatal("database is nil")
+	}
+	if !c.Database.Enable || len(c.Database.Addrs) != 2 || c.Database.Addrs[1] != "b" {
+		t.Fatalf("unexpected database: %+v", c.Database)
+	}
+	if c.Database.Timeout != 3*time.Second || c.Database.Secret != "secret" || c.Database.MaxIdleConns != 10 {
+		t.Fatalf("unexpected database: %+v", c.Database)
+	}
+}
+
+func TestOverlayInvalid(t *testing.T) {
+	t.Setenv("HFW_PORT", "port")
+
+	if err := Overlay(new(testConfiguration), "HFW"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for s, expected := range map[string]string{
+		"Name":         "name",
+		"MaxIdleConns": "max_idle_conns",
+		"HTTPAddr":     "http_addr",
+		"ID":           "id",
+		"Redis2Addr":   "redis2_addr",
+	} {
+		if name := snakeCase(s); name != expected {
+			t.Fatalf("%s: expected %s, got %s", s, expected, name)
+		}
+	}
+}
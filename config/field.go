@@ -0,0 +1,220 @@
+package config
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// durationType time.Duration
+	durationType = reflect.TypeOf(time.Duration(0))
+	// timeType time.Time
+	timeType = reflect.TypeOf(time.Time{})
+	// textUnmarshalerType encoding.TextUnmarshaler
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// tagNames 字段路径使用的标签，依次查找
+var tagNames = []string{"toml", "yaml", "json"}
+
+// field 配置结构体中的叶子字段
+type field struct {
+	// index 字段索引路径
+	index []int
+	// fields 字段路径
+	fields []reflect.StructField
+}
+
+// Key 字段路径，如: database.addrs
+func (f *field) Key() string {
+	var keys = make([]string, 0, len(f.fields))
+	for _, sf := range f.fields {
+		keys = append(keys, fieldName(sf))
+	}
+	return strings.Join(keys, ".")
+}
+
+// SnakeKey 由 snake_case 字段名组成的路径，如: database.max_idle_conns
+func (f *field) SnakeKey() string {
+	var keys = make([]string, 0, len(f.fields))
+	for _, sf := range f.fields {
+		keys = append(keys, snakeCase(sf.Name))
+	}
+	return strings.Join(keys, ".")
+}
+
+// fieldName 字段在配置文件中的名称，依次取 toml、yaml、json 标签，均未设置时为小写的字段名
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range tagNames {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); len(name) != 0 && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(sf.Name)
+}
+
+// keysOf 字段路径
+func keysOf(fields []*field) []string {
+	var keys = make([]string, 0, len(fields))
+	for _, f := range fields {
+		keys = append(keys, f.Key())
+	}
+	return keys
+}
+
+// Keys 返回 m 中设置的字段路径，用于 RawDecoder 返回配置中设置的字段
+// m 为 json、yaml、toml 等解码得到的 map，key 与字段标签(toml、yaml、json)或字段名匹配，不区分大小写
+func Keys(v interface{}, m map[string]interface{}) []string {
+	var keys = make([]string, 0)
+	for _, f := range fieldsOf(reflect.TypeOf(v)) {
+		if present(m, f.fields) {
+			keys = append(keys, f.Key())
+		}
+	}
+	return keys
+}
+
+// present 字段路径是否在 m 中
+func present(m map[string]interface{}, sfs []reflect.StructField) bool {
+	var val interface{} = m
+	for _, sf := range sfs {
+		// 未指定标签的嵌入结构体，字段与父结构体在同一层级
+		if sf.Anonymous && !tagged(sf) {
+			continue
+		}
+
+		mv, ok := val.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		var found bool
+		for key, v := range mv {
+			if matchName(sf, key) {
+				val, found = v, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// tagged 字段是否指定了 toml、yaml、json 标签名称
+func tagged(sf reflect.StructField) bool {
+	for _, tag := range tagNames {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); len(name) != 0 && name != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchName 配置中的 key 是否对应字段
+func matchName(sf reflect.StructField, key string) bool {
+	if strings.EqualFold(key, sf.Name) {
+		return true
+	}
+	for _, tag := range tagNames {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); len(name) != 0 && name != "-" && strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Last 叶子字段
+func (f *field) Last() reflect.StructField {
+	return f.fields[len(f.fields)-1]
+}
+
+// fieldsOf 遍历结构体(及结构体指针)的所有导出叶子字段
+func fieldsOf(rt reflect.Type) []*field {
+	var fields = make([]*field, 0)
+	walkFields(rt, nil, nil, map[reflect.Type]bool{}, func(f *field) {
+		fields = append(fields, f)
+	})
+	return fields
+}
+
+// walkFields .
+func walkFields(rt reflect.Type, index []int, parents []reflect.StructField, visited map[reflect.Type]bool, fn func(f *field)) {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || visited[rt] {
+		return
+	}
+
+	visited[rt] = true
+	defer delete(visited, rt)
+
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		idx := append(append(make([]int, 0, len(index)+1), index...), i)
+		sfs := append(append(make([]reflect.StructField, 0, len(parents)+1), parents...), sf)
+
+		if isStruct(sf.Type) {
+			walkFields(sf.Type, idx, sfs, visited, fn)
+		} else {
+			fn(&field{index: idx, fields: sfs})
+		}
+	}
+}
+
+// isStruct 需要展开的结构体或结构体指针
+func isStruct(rt reflect.Type) bool {
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt.Kind() == reflect.Struct && !isLeaf(rt)
+}
+
+// isLeaf 作为整体赋值的类型: time.Time 及实现 encoding.TextUnmarshaler 的类型
+func isLeaf(rt reflect.Type) bool {
+	return rt == timeType || rt.Implements(textUnmarshalerType) || reflect.PtrTo(rt).Implements(textUnmarshalerType)
+}
+
+// valueOf 根据索引路径获取字段。alloc 为 true 时为 nil 指针分配内存，否则返回无效值
+func valueOf(rv reflect.Value, index []int, alloc bool) reflect.Value {
+	for _, i := range index {
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(i)
+	}
+	return rv
+}
+
+// snakeCase CamelCase 转换为 snake_case，如: MaxIdleConns => max_idle_conns, HTTPAddr => http_addr
+func snakeCase(s string) string {
+	var (
+		runes = []rune(s)
+		b     strings.Builder
+	)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
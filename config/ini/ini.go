@@ -0,0 +1,118 @@
+package ini
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charlesbases/hfw/config"
+)
+
+// defaultConfigurationFilePath 默认配置文件路径
+const defaultConfigurationFilePath = "config.ini"
+
+// parser .
+// section 对应嵌套结构体，如: [jwt.intercept] 下的 enable 对应 Jwt.Intercept.Enable
+// key 与 toml、yaml 相同，为字段标签或 snake_case 的字段名，如: max_idle_conns 对应 MaxIdleConns
+type parser struct {
+	options *config.Options
+}
+
+func init() {
+	config.Register(".ini", NewDecoder)
+}
+
+// NewDecoder .
+func NewDecoder(opts ...config.Option) config.Decoder {
+	var options = new(config.Options)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.FilePath) == 0 {
+		options.FilePath = defaultConfigurationFilePath
+	}
+
+	return &parser{options: options}
+}
+
+// Decode .
+func (p *parser) Decode(v interface{}) error {
+	if err := config.Defaults(v); err != nil {
+		return err
+	}
+	if _, err := p.DecodeRaw(v); err != nil {
+		return err
+	}
+	return p.options.Complete(v)
+}
+
+// DecodeRaw .
+func (p *parser) DecodeRaw(v interface{}) ([]string, error) {
+	vars, err := read(p.options.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return config.OverlayKeys(v, vars)
+}
+
+// FilePath .
+func (p *parser) FilePath() string {
+	return p.options.FilePath
+}
+
+// read 读取 ini 文件，返回 section.key => value
+func read(fpath string) (map[string]string, error) {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		vars    = make(map[string]string)
+		section string
+	)
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, ";") || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("ini: %s:%d: invalid section", fpath, line)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+
+		i := strings.IndexAny(text, "=:")
+		if i == -1 {
+			return nil, fmt.Errorf("ini: %s:%d: missing '='", fpath, line)
+		}
+
+		key, val := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+		if len(section) != 0 {
+			key = section + "." + key
+		}
+		vars[key] = unquote(val)
+	}
+	return vars, scanner.Err()
+}
+
+// unquote 去除一对引号；未使用引号时去除行尾注释(" ;"、" #")
+func unquote(val string) string {
+	if len(val) > 1 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	for _, sep := range []string{" ;", " #"} {
+		if i := strings.Index(val, sep); i != -1 {
+			val = strings.TrimSpace(val[:i])
+		}
+	}
+	return val
+}
@@ -0,0 +1,67 @@
+package json
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/charlesbases/hfw/config"
+)
+
+// defaultConfigurationFilePath 默认配置文件路径
+const defaultConfigurationFilePath = "config.json"
+
+// parser .
+type parser struct {
+	options *config.Options
+}
+
+func init() {
+	config.Register(".json", NewDecoder)
+}
+
+// NewDecoder .
+func NewDecoder(opts ...config.Option) config.Decoder {
+	var options = new(config.Options)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.FilePath) == 0 {
+		options.FilePath = defaultConfigurationFilePath
+	}
+
+	return &parser{options: options}
+}
+
+// Decode .
+func (p *parser) Decode(v interface{}) error {
+	if err := config.Defaults(v); err != nil {
+		return err
+	}
+	if _, err := p.DecodeRaw(v); err != nil {
+		return err
+	}
+	return p.options.Complete(v)
+}
+
+// DecodeRaw .
+func (p *parser) DecodeRaw(v interface{}) ([]string, error) {
+	data, err := os.ReadFile(p.options.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return config.Keys(v, m), nil
+}
+
+// FilePath .
+func (p *parser) FilePath() string {
+	return p.options.FilePath
+}
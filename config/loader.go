@@ -0,0 +1,262 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// SourceDefault `default:"..."`
+	SourceDefault = "default"
+	// SourceEnv 环境变量
+	SourceEnv = "env"
+	// SourceFlag 命令行参数
+	SourceFlag = "flag"
+	// SourceFile 配置文件
+	SourceFile = "file"
+)
+
+// LoaderOptions .
+type LoaderOptions struct {
+	// Decoders 配置文件(需实现 RawDecoder)，按顺序加载，后者覆盖前者中设置的字段
+	Decoders []Decoder
+	// EnvPrefix 环境变量前缀。为空时不加载环境变量
+	EnvPrefix string
+	// Args 命令行参数，如: os.Args[1:]。为空时不加载命令行参数
+	Args []string
+	// Resolvers 解析 ${scheme:ref} 引用，默认支持 env、file
+	// 配置文件仅解码，Decoder 的 SecretResolver 不生效，需在此注册
+	Resolvers map[string]Resolver
+}
+
+type LoaderOption func(o *LoaderOptions)
+
+// LoadFiles .
+func LoadFiles(decoders ...Decoder) LoaderOption {
+	return func(o *LoaderOptions) {
+		o.Decoders = append(o.Decoders, decoders...)
+	}
+}
+
+// LoadEnv .
+func LoadEnv(prefix string) LoaderOption {
+	return func(o *LoaderOptions) {
+		o.EnvPrefix = prefix
+	}
+}
+
+// LoadArgs 命令行参数格式: -database.addrs=a,b
+func LoadArgs(args []string) LoaderOption {
+	return func(o *LoaderOptions) {
+		o.Args = args
+	}
+}
+
+// LoadResolver 注册 ${scheme:ref} 的 Resolver，如: LoadResolver("vault", r)
+func LoadResolver(scheme string, r Resolver) LoaderOption {
+	return func(o *LoaderOptions) {
+		if o.Resolvers == nil {
+			o.Resolvers = make(map[string]Resolver)
+		}
+		o.Resolvers[scheme] = r
+	}
+}
+
+// Loader 多来源配置加载器
+// 优先级(由低到高): `default:"..."` < 配置文件 < 环境变量 < 命令行参数
+type Loader struct {
+	options *LoaderOptions
+	// sources 字段路径 => 来源
+	sources map[string]string
+}
+
+// NewLoader .
+func NewLoader(opts ...LoaderOption) *Loader {
+	var options = new(LoaderOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &Loader{options: options, sources: make(map[string]string)}
+}
+
+// layer 单一来源加载结果
+type layer struct {
+	source string
+	value  reflect.Value
+	fields []*field
+}
+
+// Decode .
+func (l *Loader) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: load of non-pointer %T", v)
+	}
+
+	var layers = make([]*layer, 0, len(l.options.Decoders)+3)
+
+	// default
+	{
+		nv := reflect.New(rv.Type().Elem())
+		fields, err := defaults(nv)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, &layer{source: SourceDefault, value: nv, fields: fields})
+	}
+
+	// files
+	for _, decoder := range l.options.Decoders {
+		rd, ok := decoder.(RawDecoder)
+		if !ok {
+			return fmt.Errorf("config: load of %T which is not a RawDecoder", decoder)
+		}
+
+		nv := reflect.New(rv.Type().Elem())
+		keys, err := rd.DecodeRaw(nv.Interface())
+		if err != nil {
+			return err
+		}
+		layers = append(layers, &layer{source: fileSource(decoder), value: nv, fields: fieldsByKeys(nv.Type(), keys)})
+	}
+
+	// env
+	if len(l.options.EnvPrefix) != 0 {
+		nv := reflect.New(rv.Type().Elem())
+		fields, err := overlay(nv, l.options.EnvPrefix, os.LookupEnv)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, &layer{source: SourceEnv, value: nv, fields: fields})
+	}
+
+	// flag
+	if len(l.options.Args) != 0 {
+		nv := reflect.New(rv.Type().Elem())
+		fields, err := parseArgs(nv, l.options.Args)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, &layer{source: SourceFlag, value: nv, fields: fields})
+	}
+
+	var sources = make(map[string]string)
+	for _, layer := range layers {
+		for _, f := range layer.fields {
+			dst := valueOf(rv, f.index, true)
+			if src := valueOf(layer.value, f.index, false); src.IsValid() {
+				dst.Set(src)
+			} else {
+				dst.Set(reflect.Zero(dst.Type()))
+			}
+			sources[f.Key()] = layer.source
+		}
+	}
+	l.sources = sources
+
+	if err := Interpolate(v, l.options.Resolvers); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// Source 字段来源。未被任何来源配置时返回空字符串
+func (l *Loader) Source(key string) string {
+	return l.sources[strings.ToLower(key)]
+}
+
+// Sources 所有被配置的字段及其来源
+func (l *Loader) Sources() map[string]string {
+	var sources = make(map[string]string, len(l.sources))
+	for key, source := range l.sources {
+		sources[key] = source
+	}
+	return sources
+}
+
+// String 按字段路径排序输出来源，用于调试
+func (l *Loader) String() string {
+	var keys = make([]string, 0, len(l.sources))
+	for key := range l.sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s <= %s\n", key, l.sources[key])
+	}
+	return b.String()
+}
+
+// fieldsByKeys 字段路径对应的字段
+func fieldsByKeys(rt reflect.Type, keys []string) []*field {
+	var set = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+
+	var fields = make([]*field, 0, len(keys))
+	for _, f := range fieldsOf(rt) {
+		if set[f.Key()] {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// fileSource .
+func fileSource(decoder Decoder) string {
+	if fd, ok := decoder.(FileDecoder); ok {
+		return SourceFile + ":" + fd.FilePath()
+	}
+	return SourceFile
+}
+
+// flagValue .
+type flagValue struct {
+	isBool bool
+	set    func(val string) error
+}
+
+func (f *flagValue) String() string {
+	return ""
+}
+
+func (f *flagValue) Set(val string) error {
+	return f.set(val)
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// parseArgs 返回被命令行参数设置的字段
+func parseArgs(rv reflect.Value, args []string) ([]*field, error) {
+	var found = make([]*field, 0)
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	for _, f := range fieldsOf(rv.Type()) {
+		f := f
+		fs.Var(&flagValue{
+			isBool: f.Last().Type.Kind() == reflect.Bool,
+			set: func(val string) error {
+				if err := setValue(valueOf(rv, f.index, true), val); err != nil {
+					return err
+				}
+				found = append(found, f)
+				return nil
+			},
+		}, f.Key(), f.Key())
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return found, fmt.Errorf("config: flag: %v", err)
+	}
+	return found, nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+type testLoaderConfiguration struct {
+	Name     string `default:"app"`
+	Port     string `default:":8080"`
+	Started  time.Time
+	Database *testLoaderDatabase
+}
+
+type testLoaderDatabase struct {
+	Debug   bool
+	Addrs   []string
+	Timeout time.Duration `default:"3s"`
+
+	MaxIdleConns int `toml:"max_idle_conns" yaml:"max_idle_conns"`
+}
+
+// decoderFunc RawDecoder，返回设置的字段路径
+type decoderFunc func(v interface{}) []string
+
+func (fn decoderFunc) Decode(v interface{}) error {
+	_, err := fn.DecodeRaw(v)
+	return err
+}
+
+func (fn decoderFunc) DecodeRaw(v interface{}) ([]string, error) {
+	return fn(v), nil
+}
+
+func TestLoader(t *testing.T) {
+	t.Setenv("HFW_PORT", ":9090")
+	started := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	file := decoderFunc(func(v interface{}) []string {
+		c := v.(*testLoaderConfiguration)
+		c.Name = "file"
+		c.Port = ":8081"
+		c.Started = started
+		c.Database = &testLoaderDatabase{Addrs: []string{"a"}}
+		return []string{"name", "port", "started", "database.addrs"}
+	})
+	// 后者覆盖前者设置的字段，包括零值
+	zero := decoderFunc(func(v interface{}) []string {
+		v.(*testLoaderConfiguration).Database = &testLoaderDatabase{}
+		return []string{"database.timeout"}
+	})
+
+	loader := NewLoader(LoadFiles(file, zero), LoadEnv("HFW"), LoadArgs([]string{"-database.debug", "-database.addrs=b,c", "-database.max_idle_conns=5"}))
+
+	var c = new(testLoaderConfiguration)
+	if err := loader.Decode(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "file" || c.Port != ":9090" || !c.Started.Equal(started) {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+	if !c.Database.Debug || len(c.Database.Addrs) != 2 || c.Database.Timeout != 0 || c.Database.MaxIdleConns != 5 {
+		t.Fatalf("unexpected database: %+v", c.Database)
+	}
+
+	for key, source := range map[string]string{
+		"name":                    SourceFile,
+		"port":                    SourceEnv,
+		"started":                 SourceFile,
+		"database.debug":          SourceFlag,
+		"database.addrs":          SourceFlag,
+		"database.timeout":        SourceFile,
+		"database.max_idle_conns": SourceFlag,
+	} {
+		if s := loader.Source(key); s != source {
+			t.Fatalf("%s: expected source %s, got %s", key, source, s)
+		}
+	}
+	t.Log("\n" + loader.String())
+}
+
+func TestLoaderResolver(t *testing.T) {
+	file := decoderFunc(func(v interface{}) []string {
+		v.(*testLoaderConfiguration).Name = "${vault:name}"
+		return []string{"name"}
+	})
+
+	var c = new(testLoaderConfiguration)
+	if err := NewLoader(LoadFiles(file)).Decode(c); err == nil {
+		t.Fatal("expected error of unknown resolver")
+	}
+
+	loader := NewLoader(LoadFiles(file), LoadResolver("vault", MapResolver{"name": "vault"}))
+	if err := loader.Decode(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "vault" {
+		t.Fatalf("unexpected name: %s", c.Name)
+	}
+}
+
+func TestLoaderDecoder(t *testing.T) {
+	// 仅支持 RawDecoder，避免对单个来源执行默认值、校验等
+	var decoder = struct{ Decoder }{decoderFunc(func(v interface{}) []string { return nil })}
+	if err := NewLoader(LoadFiles(decoder)).Decode(new(testLoaderConfiguration)); err == nil {
+		t.Fatal("expected error of non-RawDecoder")
+	}
+}
@@ -4,6 +4,10 @@ package config
 type Options struct {
 	// FilePath 配置文件路径
 	FilePath string
+	// EnvPrefix 环境变量前缀。为空时不使用环境变量覆盖配置
+	EnvPrefix string
+	// Resolvers 解析 ${scheme:ref} 引用，默认支持 env、file
+	Resolvers map[string]Resolver
 }
 
 type Option func(o *Options)
@@ -14,3 +18,34 @@ func FilePath(fpath string) Option {
 		o.FilePath = fpath
 	}
 }
+
+// EnvPrefix 使用环境变量覆盖配置，如: EnvPrefix("HFW") => HFW_DATABASE_ADDRS
+func EnvPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.EnvPrefix = prefix
+	}
+}
+
+// SecretResolver 注册 ${scheme:ref} 的 Resolver，如: SecretResolver("vault", r)
+func SecretResolver(scheme string, r Resolver) Option {
+	return func(o *Options) {
+		if o.Resolvers == nil {
+			o.Resolvers = make(map[string]Resolver)
+		}
+		o.Resolvers[scheme] = r
+	}
+}
+
+// Complete 配置文件解码后: 环境变量覆盖、展开引用、校验
+// 默认值需在解码前使用 Defaults 填充，配置文件中的值(包括 false、0 等零值)覆盖默认值
+func (o *Options) Complete(v interface{}) error {
+	if len(o.EnvPrefix) != 0 {
+		if err := Overlay(v, o.EnvPrefix); err != nil {
+			return err
+		}
+	}
+	if err := Interpolate(v, o.Resolvers); err != nil {
+		return err
+	}
+	return Validate(v)
+}
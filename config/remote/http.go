@@ -0,0 +1,89 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/charlesbases/hfw/config"
+	"github.com/charlesbases/hfw/content"
+	"github.com/charlesbases/hfw/xhttp/webhttp"
+)
+
+// httpSource .
+type httpSource struct {
+	url string
+	// raw 响应不是 webhttp.Response
+	raw bool
+}
+
+// NewHTTPDecoder 通过 webhttp 从 url 加载配置，响应为 webhttp.Response
+// Response.Data 为 json 对象时按 json 解析；为字符串时，根据 url 的扩展名判断格式
+func NewHTTPDecoder(url string, opts ...config.Option) config.StateDecoder {
+	return newDecoder(&httpSource{url: url}, opts...)
+}
+
+// NewRawHTTPDecoder 从 url 加载配置，响应内容即配置文件
+// 根据响应的 Content-Type(或 url 的扩展名)判断格式
+func NewRawHTTPDecoder(url string, opts ...config.Option) config.StateDecoder {
+	return newDecoder(&httpSource{url: url, raw: true}, opts...)
+}
+
+func (s *httpSource) name() string {
+	if u, err := url.Parse(s.url); err == nil {
+		return u.Path
+	}
+	return s.url
+}
+
+func (s *httpSource) fetch(state string) (*document, error) {
+	var key, val string
+	switch {
+	case strings.HasPrefix(state, stateETag):
+		key, val = "If-None-Match", strings.TrimPrefix(state, stateETag)
+	case strings.HasPrefix(state, stateModified):
+		key, val = "If-Modified-Since", strings.TrimPrefix(state, stateModified)
+	}
+
+	rsp, err := webhttp.Get(s.url, webhttp.Header(key, val), webhttp.Raw(s.raw))
+	if err != nil {
+		return nil, err
+	}
+	if rsp.NotModified() {
+		return nil, nil
+	}
+
+	var doc = &document{data: rsp.Bytes(), contentType: content.Json}
+
+	switch {
+	case s.raw:
+		if ct, found := content.Parse(rsp.Header().Get("Content-Type")); found {
+			doc.contentType = ct
+		} else {
+			doc.contentType = content.Stream
+		}
+	case strings.HasPrefix(strings.TrimSpace(string(doc.data)), `"`):
+		// 字符串格式的配置
+		var text string
+		if err := json.Unmarshal(doc.data, &text); err != nil {
+			return nil, err
+		}
+		doc.data, doc.contentType = []byte(text), content.Stream
+	}
+
+	switch {
+	case len(rsp.Header().Get("ETag")) != 0:
+		doc.state = stateETag + rsp.Header().Get("ETag")
+	case len(rsp.Header().Get("Last-Modified")) != 0:
+		doc.state = stateModified + rsp.Header().Get("Last-Modified")
+	default:
+		sum := sha256.Sum256(doc.data)
+		doc.state = stateSum + hex.EncodeToString(sum[:])
+	}
+	if doc.state == state {
+		return nil, nil
+	}
+	return doc, nil
+}
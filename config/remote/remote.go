@@ -0,0 +1,162 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charlesbases/hfw/config"
+	"github.com/charlesbases/hfw/content"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsupportedFormat unsupported format of remote configuration
+var ErrUnsupportedFormat = errors.New("remote: unsupported configuration format")
+
+// 配置状态的前缀
+const (
+	// stateETag ETag
+	stateETag = "etag:"
+	// stateModified 修改时间
+	stateModified = "modified:"
+	// stateSum 无 ETag、修改时间时使用内容的 sha256
+	stateSum = "sha256:"
+)
+
+// document 远程配置内容
+type document struct {
+	data        []byte
+	contentType content.Type
+	// state ETag、修改时间等
+	state string
+}
+
+// source 远程配置来源
+type source interface {
+	// fetch state 未变化时返回 nil
+	fetch(state string) (*document, error)
+	// name 用于日志及根据扩展名判断格式
+	name() string
+}
+
+// decoder .
+// 实现 config.StateDecoder、config.RawDecoder，配合 config.Watch 定时刷新或作为 config.Loader 的来源:
+// config.Watch(decoder, v, onChange, config.WatchInterval(time.Minute))
+type decoder struct {
+	options *config.Options
+	source  source
+
+	mu  sync.Mutex
+	doc *document
+}
+
+// newDecoder .
+func newDecoder(s source, opts ...config.Option) *decoder {
+	var options = new(config.Options)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &decoder{options: options, source: s}
+}
+
+// Decode .
+func (d *decoder) Decode(v interface{}) error {
+	if err := config.Defaults(v); err != nil {
+		return err
+	}
+	if _, err := d.DecodeRaw(v); err != nil {
+		return err
+	}
+	return d.options.Complete(v)
+}
+
+// DecodeRaw .
+func (d *decoder) DecodeRaw(v interface{}) ([]string, error) {
+	doc, err := d.document()
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := unmarshal(doc, d.source.name(), &m); err != nil {
+		return nil, err
+	}
+	if err := unmarshal(doc, d.source.name(), v); err != nil {
+		return nil, err
+	}
+	return config.Keys(v, m), nil
+}
+
+// State 获取最新的配置，未变化时返回原状态
+func (d *decoder) State() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.refresh(); err != nil {
+		return "", err
+	}
+	return d.doc.state, nil
+}
+
+// document 返回缓存的配置，未获取过时从远程获取
+func (d *decoder) document() (*document, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.doc == nil {
+		if err := d.refresh(); err != nil {
+			return nil, err
+		}
+	}
+	return d.doc, nil
+}
+
+// refresh .
+func (d *decoder) refresh() error {
+	var state string
+	if d.doc != nil {
+		state = d.doc.state
+	}
+
+	doc, err := d.source.fetch(state)
+	if err != nil {
+		return fmt.Errorf("remote: fetch %s failed. %v", d.source.name(), err)
+	}
+	if doc != nil {
+		d.doc = doc
+	}
+	return nil
+}
+
+// unmarshal 根据 content-type 解析配置，无法判断时使用扩展名
+func unmarshal(doc *document, name string, v interface{}) error {
+	ct := doc.contentType
+	switch ct {
+	case content.Json, content.Yaml, content.Toml:
+	default:
+		switch strings.ToLower(path.Ext(name)) {
+		case ".json":
+			ct = content.Json
+		case ".yaml", ".yml":
+			ct = content.Yaml
+		case ".toml":
+			ct = content.Toml
+		default:
+			return fmt.Errorf("%w: %s of %s", ErrUnsupportedFormat, doc.contentType, name)
+		}
+	}
+
+	switch ct {
+	case content.Json:
+		return json.Unmarshal(doc.data, v)
+	case content.Yaml:
+		return yaml.Unmarshal(doc.data, v)
+	default:
+		return toml.Unmarshal(doc.data, v)
+	}
+}
@@ -0,0 +1,156 @@
+package remote
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/hfw/config"
+	"github.com/charlesbases/hfw/content"
+	"github.com/charlesbases/hfw/storage"
+)
+
+type testConfiguration struct {
+	Name string
+	Port string
+}
+
+func TestHTTPDecoder(t *testing.T) {
+	var (
+		version  = 1
+		requests = 0
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		etag := fmt.Sprintf(`"%d"`, version)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"code": 200, "data": "name: app%d\nport: ':8080'\n"}`, version)
+	}))
+	defer server.Close()
+
+	decoder := NewHTTPDecoder(server.URL + "/config.yaml")
+
+	var c = new(testConfiguration)
+	if err := decoder.Decode(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "app1" || c.Port != ":8080" {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+
+	state, err := decoder.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version++
+	next, err := decoder.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state == next {
+		t.Fatal("expected state changed")
+	}
+	if err := decoder.Decode(c); err != nil || c.Name != "app2" {
+		t.Fatalf("unexpected configuration: %+v %v", c, err)
+	}
+	if requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests)
+	}
+}
+
+// testStorage .
+type testStorage struct {
+	storage.Storage
+
+	data  string
+	etag  string
+	reads int
+}
+
+func (s *testStorage) GetObject(bucket, key string, opts ...storage.GetOption) (storage.Object, error) {
+	if o := storage.ParseGetOptions(opts...); len(o.IfNoneMatch) != 0 && o.IfNoneMatch == s.etag {
+		return nil, storage.ErrNotModified
+	}
+	s.reads++
+	return storage.ReadCloser(io.NopCloser(strings.NewReader(s.data)), int64(len(s.data)), time.Time{}, storage.ContentType(content.Json), storage.ETag(s.etag)), nil
+}
+
+func TestStorageDecoder(t *testing.T) {
+	s := &testStorage{data: `{"name": "app", "port": ":8080"}`, etag: `"1"`}
+	decoder := NewStorageDecoder(s, "bucket", "config")
+
+	var c = new(testConfiguration)
+	if err := decoder.Decode(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "app" || c.Port != ":8080" {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+
+	state, _ := decoder.State()
+	if next, _ := decoder.State(); next != state {
+		t.Fatal("expected state unchanged")
+	}
+	if s.reads != 1 {
+		t.Fatalf("expected 1 read, got %d", s.reads)
+	}
+
+	s.data, s.etag = `{"name": "app2"}`, `"2"`
+	if next, _ := decoder.State(); next == state {
+		t.Fatal("expected state changed")
+	}
+	if err := decoder.Decode(c); err != nil || c.Name != "app2" {
+		t.Fatalf("unexpected configuration: %+v %v", c, err)
+	}
+}
+
+func TestRawHTTPDecoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/toml")
+		fmt.Fprint(w, "name = 'app'\nport = ':8080'\n")
+	}))
+	defer server.Close()
+
+	var c = new(testConfiguration)
+	if err := NewRawHTTPDecoder(server.URL + "/config").Decode(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "app" || c.Port != ":8080" {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+}
+
+func TestLoaderRemote(t *testing.T) {
+	t.Setenv("HFW_NAME", "env")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"port": ":8080"}`)
+	}))
+	defer server.Close()
+
+	// 必填字段由环境变量设置，远程配置仅解码，不单独校验
+	type configuration struct {
+		Name string `validate:"required"`
+		Port string
+	}
+
+	loader := config.NewLoader(config.LoadFiles(NewRawHTTPDecoder(server.URL)), config.LoadEnv("HFW"))
+	var c = new(configuration)
+	if err := loader.Decode(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "env" || c.Port != ":8080" || loader.Source("port") != config.SourceFile {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+}
@@ -0,0 +1,88 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charlesbases/hfw/config"
+	"github.com/charlesbases/hfw/storage"
+)
+
+// storageSource .
+type storageSource struct {
+	client storage.Storage
+	bucket string
+	key    string
+}
+
+// NewStorageDecoder 从 storage.Storage 加载配置，根据对象的 content-type(或 key 的扩展名)判断格式
+// 刷新时使用 ETag(或修改时间)发起条件请求，对象未变化时不下载内容
+func NewStorageDecoder(client storage.Storage, bucket, key string, opts ...config.Option) config.StateDecoder {
+	return newDecoder(&storageSource{client: client, bucket: bucket, key: key}, opts...)
+}
+
+func (s *storageSource) name() string {
+	return s.bucket + "/" + s.key
+}
+
+func (s *storageSource) fetch(state string) (*document, error) {
+	var opts = []storage.GetOption{storage.GetDisableDebug()}
+	switch {
+	case strings.HasPrefix(state, stateETag):
+		opts = append(opts, storage.GetIfNoneMatch(strings.TrimPrefix(state, stateETag)))
+	case strings.HasPrefix(state, stateModified):
+		if n, err := strconv.ParseInt(strings.TrimPrefix(state, stateModified), 10, 64); err == nil {
+			opts = append(opts, storage.GetIfModifiedSince(time.Unix(0, n)))
+		}
+	}
+
+	obj, err := s.client.GetObject(s.bucket, s.key, opts...)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotModified) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if obj.DeferFunc() != nil {
+		defer obj.DeferFunc()()
+	}
+
+	// Storage 不支持条件请求时，ETag 或修改时间未变化则不读取内容
+	var current string
+	switch {
+	case len(obj.ETag()) != 0:
+		current = stateETag + obj.ETag()
+	case !obj.Modify().IsZero():
+		current = stateModified + strconv.FormatInt(obj.Modify().UnixNano(), 10)
+	}
+	if len(current) != 0 && current == state {
+		return nil, nil
+	}
+
+	var reader io.Reader = obj.ReadCloser()
+	if obj.ReadCloser() == nil {
+		reader = obj.ReadSeeker()
+	}
+	if reader == nil {
+		return nil, fmt.Errorf("empty object")
+	}
+
+	buff := new(bytes.Buffer)
+	if _, err := io.Copy(buff, reader); err != nil {
+		return nil, err
+	}
+
+	// 未提供 ETag 及修改时间时使用内容摘要
+	if len(current) == 0 {
+		sum := sha256.Sum256(buff.Bytes())
+		current = stateSum + hex.EncodeToString(sum[:])
+	}
+	return &document{data: buff.Bytes(), contentType: obj.ContentType(), state: current}, nil
+}
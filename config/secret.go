@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	// SchemeEnv ${env:DB_PASSWORD}
+	SchemeEnv = "env"
+	// SchemeFile ${file:/run/secrets/s3_key}
+	SchemeFile = "file"
+)
+
+// Resolver 解析配置中的引用，如: vault、kms
+type Resolver interface {
+	// Resolve 返回 ref 对应的值。ref 不存在时返回 false
+	Resolve(ref string) (string, bool, error)
+}
+
+// ResolverFunc .
+type ResolverFunc func(ref string) (string, bool, error)
+
+// Resolve .
+func (fn ResolverFunc) Resolve(ref string) (string, bool, error) {
+	return fn(ref)
+}
+
+// EnvResolver 读取环境变量
+var EnvResolver = ResolverFunc(func(ref string) (string, bool, error) {
+	val, found := os.LookupEnv(ref)
+	return val, found, nil
+})
+
+// FileResolver 读取文件内容，去除末尾换行
+var FileResolver = ResolverFunc(func(ref string) (string, bool, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+})
+
+// MapResolver 从 map 中读取，用于测试
+type MapResolver map[string]string
+
+// Resolve .
+func (m MapResolver) Resolve(ref string) (string, bool, error) {
+	val, found := m[ref]
+	return val, found, nil
+}
+
+// defaultResolvers .
+func defaultResolvers() map[string]Resolver {
+	return map[string]Resolver{
+		SchemeEnv:  EnvResolver,
+		SchemeFile: FileResolver,
+	}
+}
+
+// Interpolate 展开配置中所有字符串的引用
+//   - ${scheme:ref}: 使用 scheme 对应的 Resolver 解析，如: ${env:DB_PASSWORD} ${file:/run/secrets/s3_key}
+//   - ${VAR}: 等同于 ${env:VAR}
+//   - ${ref:-default}: ref 不存在或为空时使用 default
+//   - $${: 转义为 ${
+//
+// resolvers 会覆盖同名的默认 Resolver(env、file)
+func Interpolate(v interface{}, resolvers map[string]Resolver) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: interpolate of non-pointer %T", v)
+	}
+
+	var rs = defaultResolvers()
+	for scheme, r := range resolvers {
+		rs[scheme] = r
+	}
+	return interpolate(rv, "", rs)
+}
+
+// interpolate .
+func interpolate(rv reflect.Value, key string, resolvers map[string]Resolver) error {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Kind() == reflect.Interface {
+			// interface 中的值不可寻址，展开后重新赋值
+			if s, ok := rv.Interface().(string); ok && rv.CanSet() {
+				val, err := expand(s, resolvers)
+				if err != nil {
+					return fmt.Errorf("config: %s: %v", key, err)
+				}
+				rv.Set(reflect.ValueOf(val))
+				return nil
+			}
+		}
+		return interpolate(rv.Elem(), key, resolvers)
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			if sf := rt.Field(i); sf.IsExported() {
+				if err := interpolate(rv.Field(i), join(key, fieldName(sf)), resolvers); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := interpolate(rv.Index(i), fmt.Sprintf("%s[%d]", key, i), resolvers); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			ev := reflect.New(iter.Value().Type()).Elem()
+			ev.Set(iter.Value())
+			if err := interpolate(ev, join(key, fmt.Sprint(iter.Key().Interface())), resolvers); err != nil {
+				return err
+			}
+			rv.SetMapIndex(iter.Key(), ev)
+		}
+	case reflect.String:
+		if !rv.CanSet() {
+			return nil
+		}
+		val, err := expand(rv.String(), resolvers)
+		if err != nil {
+			return fmt.Errorf("config: %s: %v", key, err)
+		}
+		rv.SetString(val)
+	}
+	return nil
+}
+
+// join .
+func join(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// expand 展开字符串中的引用
+func expand(s string, resolvers map[string]Resolver) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for len(s) != 0 {
+		i := strings.Index(s, "${")
+		if i == -1 {
+			b.WriteString(s)
+			break
+		}
+
+		// $${ 转义
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+
+		j := strings.Index(s[i:], "}")
+		if j == -1 {
+			return "", fmt.Errorf("unclosed reference %q", s[i:])
+		}
+
+		val, err := resolve(s[i+2:i+j], resolvers)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(s[:i])
+		b.WriteString(val)
+		s = s[i+j+1:]
+	}
+	return b.String(), nil
+}
+
+// resolve 解析 ${...} 中的内容
+func resolve(expr string, resolvers map[string]Resolver) (string, error) {
+	ref, def, hasDefault := strings.Cut(expr, ":-")
+
+	var resolver Resolver = EnvResolver
+	if scheme, name, found := strings.Cut(ref, ":"); found {
+		r, ok := resolvers[scheme]
+		if !ok {
+			return "", fmt.Errorf("unknown resolver %q of ${%s}", scheme, expr)
+		}
+		resolver, ref = r, name
+	} else if r, ok := resolvers[SchemeEnv]; ok {
+		resolver = r
+	}
+
+	val, found, err := resolver.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve ${%s} failed. %v", expr, err)
+	}
+	if !found || len(val) == 0 {
+		if hasDefault {
+			return def, nil
+		}
+		if !found {
+			return "", fmt.Errorf("${%s} not found", expr)
+		}
+	}
+	return val, nil
+}
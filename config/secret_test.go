@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testSecretConfiguration struct {
+	DSN     string
+	Keys    []string
+	Extra   map[string]interface{}
+	Storage *testSecretStorage
+}
+
+type testSecretStorage struct {
+	AccessKey string
+	SecretKey string
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "pass")
+
+	secret := filepath.Join(t.TempDir(), "s3_key")
+	if err := os.WriteFile(secret, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var c = &testSecretConfiguration{
+		DSN:     "root:${env:DB_PASSWORD}@tcp(${DB_HOST:-127.0.0.1}:3306)/app",
+		Keys:    []string{"${vault:key}", "$${literal}"},
+		Extra:   map[string]interface{}{"token": "${vault:token}"},
+		Storage: &testSecretStorage{AccessKey: "${ACCESS_KEY:-}", SecretKey: "${file:" + secret + "}"},
+	}
+
+	if err := Interpolate(c, map[string]Resolver{"vault": MapResolver{"key": "k", "token": "t"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.DSN != "root:pass@tcp(127.0.0.1:3306)/app" {
+		t.Fatalf("unexpected dsn: %s", c.DSN)
+	}
+	if c.Keys[0] != "k" || c.Keys[1] != "${literal}" || c.Extra["token"] != "t" {
+		t.Fatalf("unexpected configuration: %+v", c)
+	}
+	if c.Storage.AccessKey != "" || c.Storage.SecretKey != "secret" {
+		t.Fatalf("unexpected storage: %+v", c.Storage)
+	}
+}
+
+func TestInterpolateNotFound(t *testing.T) {
+	for _, s := range []string{"${HFW_NOT_FOUND}", "${unknown:ref}", "${env:HFW_NOT_FOUND"} {
+		if err := Interpolate(&testSecretConfiguration{DSN: s}, nil); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+}
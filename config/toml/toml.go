@@ -13,6 +13,10 @@ type parser struct {
 	options *config.Options
 }
 
+func init() {
+	config.Register(".toml", NewDecoder)
+}
+
 // NewDecoder .
 func NewDecoder(opts ...config.Option) config.Decoder {
 	var options = new(config.Options)
@@ -29,6 +33,28 @@ func NewDecoder(opts ...config.Option) config.Decoder {
 
 // Decode .
 func (p *parser) Decode(v interface{}) error {
-	_, err := toml.DecodeFile(p.options.FilePath, v)
-	return err
+	if err := config.Defaults(v); err != nil {
+		return err
+	}
+	if _, err := p.DecodeRaw(v); err != nil {
+		return err
+	}
+	return p.options.Complete(v)
+}
+
+// DecodeRaw .
+func (p *parser) DecodeRaw(v interface{}) ([]string, error) {
+	var m map[string]interface{}
+	if _, err := toml.DecodeFile(p.options.FilePath, &m); err != nil {
+		return nil, err
+	}
+	if _, err := toml.DecodeFile(p.options.FilePath, v); err != nil {
+		return nil, err
+	}
+	return config.Keys(v, m), nil
+}
+
+// FilePath .
+func (p *parser) FilePath() string {
+	return p.options.FilePath
 }
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError 字段校验错误
+type FieldError struct {
+	// Key 字段路径，如: database.addrs
+	Key string
+	// Rule 校验规则，如: required | min=1
+	Rule string
+	// Message .
+	Message string
+}
+
+// Error .
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// ValidationErrors 所有字段的校验错误
+type ValidationErrors []*FieldError
+
+// Error .
+func (es ValidationErrors) Error() string {
+	var msgs = make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return "config: validation failed. " + strings.Join(msgs, "; ")
+}
+
+// Validate 根据 `validate:"..."` 校验配置，返回 ValidationErrors
+// 支持的规则:
+//   - required: 非零值
+//   - required_if=Field value: 同级字段 Field 等于 value 时非零值
+//   - min=n | max=n: 数值大小，字符串、切片、map 的长度
+//   - oneof=a b c: 取值范围
+//
+// 结构体指针为 nil 时，不校验其内部字段
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: validate of non-pointer %T", v)
+	}
+	if rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: validate of non-struct %T", v)
+	}
+
+	var errs = make(ValidationErrors, 0)
+	validateStruct(rv.Elem(), "", &errs)
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct .
+func validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		key := fieldName(sf)
+		if len(prefix) != 0 {
+			key = prefix + "." + key
+		}
+
+		fv := rv.Field(i)
+		if tag := sf.Tag.Get("validate"); len(tag) != 0 {
+			for _, rule := range strings.Split(tag, ",") {
+				if msg := check(rv, fv, strings.TrimSpace(rule)); len(msg) != 0 {
+					*errs = append(*errs, &FieldError{Key: key, Rule: rule, Message: msg})
+				}
+			}
+		}
+
+		validateValue(fv, key, errs)
+	}
+}
+
+// validateValue 校验嵌套结构体及结构体切片
+func validateValue(rv reflect.Value, key string, errs *ValidationErrors) {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			validateValue(rv.Elem(), key, errs)
+		}
+	case reflect.Struct:
+		if !isLeaf(rv.Type()) {
+			validateStruct(rv, key, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			validateValue(rv.Index(i), fmt.Sprintf("%s[%d]", key, i), errs)
+		}
+	}
+}
+
+// check 返回错误信息，校验通过时返回空字符串
+func check(parent reflect.Value, rv reflect.Value, rule string) string {
+	name, param := rule, ""
+	if i := strings.Index(rule, "="); i != -1 {
+		name, param = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case "":
+		return ""
+	case "required":
+		if rv.IsZero() {
+			return "is required"
+		}
+	case "required_if":
+		fields := strings.Fields(param)
+		if len(fields) != 2 {
+			return fmt.Sprintf("invalid rule %q", rule)
+		}
+		other := parent.FieldByName(fields[0])
+		if !other.IsValid() {
+			return fmt.Sprintf("invalid rule %q, field %s not found", rule, fields[0])
+		}
+		// 同级字段为 nil 指针时视为未设置
+		if ov := reflect.Indirect(other); ov.IsValid() && fmt.Sprint(ov.Interface()) == fields[1] && rv.IsZero() {
+			return fmt.Sprintf("is required when %s is %s", fields[0], fields[1])
+		}
+	case "min", "max":
+		n, size, ok := measure(rv, param)
+		if !ok {
+			return fmt.Sprintf("invalid rule %q", rule)
+		}
+		if name == "min" && size < n {
+			return fmt.Sprintf("must be at least %s", param)
+		}
+		if name == "max" && size > n {
+			return fmt.Sprintf("must be at most %s", param)
+		}
+	case "oneof":
+		if rv.IsZero() {
+			return ""
+		}
+		val := fmt.Sprint(reflect.Indirect(rv).Interface())
+		for _, option := range strings.Fields(param) {
+			if val == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s], got %q", param, val)
+	default:
+		return fmt.Sprintf("unknown rule %q", rule)
+	}
+	return ""
+}
+
+// measure 返回规则参数与字段的大小。字符串、切片、map 取长度
+func measure(rv reflect.Value, param string) (float64, float64, bool) {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv = reflect.Zero(rv.Type().Elem())
+		} else {
+			rv = rv.Elem()
+		}
+	}
+
+	if rv.Type() == durationType {
+		d, err := parseDuration(param)
+		if err != nil {
+			return 0, 0, false
+		}
+		return float64(d), float64(time.Duration(rv.Int())), true
+	}
+
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return n, float64(rv.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return n, float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return n, float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return n, rv.Float(), true
+	default:
+		return 0, 0, false
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testValidateConfiguration struct {
+	Name     string `validate:"required"`
+	Port     int    `default:"8080" validate:"min=1,max=65535"`
+	Level    string `default:"info" validate:"oneof=debug info warn error"`
+	Database *testValidateDatabase
+}
+
+type testValidateDatabase struct {
+	Enable  bool
+	Addrs   []string      `validate:"required_if=Enable true"`
+	Timeout time.Duration `validate:"max=1m"`
+}
+
+func TestValidate(t *testing.T) {
+	var c = &testValidateConfiguration{
+		Level:    "trace",
+		Database: &testValidateDatabase{Enable: true, Timeout: time.Hour},
+	}
+	if err := Defaults(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 8080 || c.Level != "trace" {
+		t.Fatalf("unexpected defaults: %+v", c)
+	}
+
+	err := Validate(c)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+
+	var keys = make(map[string]bool)
+	for _, e := range errs {
+		keys[e.Key] = true
+	}
+	for _, key := range []string{"name", "level", "database.addrs", "database.timeout"} {
+		if !keys[key] {
+			t.Fatalf("expected error of %s, got %v", key, err)
+		}
+	}
+	if len(errs) != 4 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+func TestValidateNilSection(t *testing.T) {
+	var c = &testValidateConfiguration{Name: "app", Port: 80}
+	if err := Validate(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	var s = "config"
+	if err := Validate(&s); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := NewLoader().Decode(&s); err == nil {
+		t.Fatal("expected error")
+	}
+
+	var c = &struct {
+		Enable *bool
+		Addrs  []string `validate:"required_if=Enable true"`
+	}{}
+	if err := Validate(c); err != nil {
+		t.Fatal(err)
+	}
+
+	enable := true
+	c.Enable = &enable
+	var errs ValidationErrors
+	if err := Validate(c); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "addrs" {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
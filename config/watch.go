@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charlesbases/logger"
+)
+
+const (
+	// defaultWatchInterval 默认轮询间隔
+	defaultWatchInterval = time.Second
+	// defaultWatchDebounce 默认防抖时间。文件在此期间无变化时才重新加载
+	defaultWatchDebounce = 500 * time.Millisecond
+)
+
+// WatchOptions .
+type WatchOptions struct {
+	// Interval 轮询间隔
+	Interval time.Duration
+	// Debounce 防抖时间
+	Debounce time.Duration
+	// Validate 重新加载后校验配置，校验失败时保留原配置
+	Validate func(v interface{}) error
+	// ticker 触发轮询的 channel，为空时使用 Interval 的 time.Ticker。仅用于测试
+	ticker <-chan time.Time
+}
+
+type WatchOption func(o *WatchOptions)
+
+// WatchInterval .
+func WatchInterval(d time.Duration) WatchOption {
+	return func(o *WatchOptions) {
+		if d > 0 {
+			o.Interval = d
+		}
+	}
+}
+
+// WatchDebounce .
+func WatchDebounce(d time.Duration) WatchOption {
+	return func(o *WatchOptions) {
+		if d >= 0 {
+			o.Debounce = d
+		}
+	}
+}
+
+// WatchValidate .
+func WatchValidate(fn func(v interface{}) error) WatchOption {
+	return func(o *WatchOptions) {
+		o.Validate = fn
+	}
+}
+
+// Watcher .
+type Watcher struct {
+	options *WatchOptions
+
+	decoder  Decoder
+	onChange func(old, new interface{})
+
+	typ     reflect.Type
+	current atomic.Value
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Watch 监听配置文件(或 StateDecoder 的状态)，变化时重新加载
+// v 为已加载的配置指针，作为初始配置且不会被修改。重新加载的配置通过 Get 获取
+// onChange 在替换后调用，参数为新旧配置的指针
+func Watch(decoder Decoder, v interface{}, onChange func(old, new interface{}), opts ...WatchOption) (*Watcher, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, fmt.Errorf("config: watch of non-pointer %T", v)
+	}
+
+	if !watchable(decoder) {
+		return nil, fmt.Errorf("config: watch of %T without file or state", decoder)
+	}
+
+	var options = &WatchOptions{Interval: defaultWatchInterval, Debounce: defaultWatchDebounce}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	w := &Watcher{
+		options:  options,
+		decoder:  decoder,
+		onChange: onChange,
+		typ:      rv.Type().Elem(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.current.Store(v)
+	go w.run()
+	return w, nil
+}
+
+// watchable .
+func watchable(decoder Decoder) bool {
+	switch d := decoder.(type) {
+	case FileDecoder, StateDecoder:
+		return true
+	case *Loader:
+		for _, decoder := range d.options.Decoders {
+			if watchable(decoder) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Get 当前配置的指针，类型与 Watch 的 v 相同。每次重新加载均为新的指针，调用方不应修改其内容
+func (w *Watcher) Get() interface{} {
+	return w.current.Load()
+}
+
+// Stop 停止监听
+func (w *Watcher) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+// run .
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	tick := w.options.ticker
+	if tick == nil {
+		ticker := time.NewTicker(w.options.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var (
+		state   = stateOf(w.decoder)
+		pending bool
+		changed time.Time
+	)
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-tick:
+			if current := stateOf(w.decoder); current != state {
+				state, pending, changed = current, true, time.Now()
+				continue
+			}
+			if pending && time.Since(changed) >= w.options.Debounce {
+				pending = false
+				if err := w.reload(); err != nil {
+					logger.Errorf("[config] reload failed, keep the last good config. %v", err)
+				}
+			}
+		}
+	}
+}
+
+// stateOf 配置状态，用于判断配置是否变化
+func stateOf(decoder Decoder) string {
+	switch d := decoder.(type) {
+	case FileDecoder:
+		if info, err := os.Stat(d.FilePath()); err != nil {
+			return fmt.Sprintf("%s:-;", d.FilePath())
+		} else {
+			return fmt.Sprintf("%s:%d:%d;", d.FilePath(), info.ModTime().UnixNano(), info.Size())
+		}
+	case StateDecoder:
+		state, err := d.State()
+		if err != nil {
+			logger.Errorf("[config] get state of %T failed. %v", d, err)
+			return "-;"
+		}
+		return state + ";"
+	case *Loader:
+		var state string
+		for _, decoder := range d.options.Decoders {
+			state += stateOf(decoder)
+		}
+		return state
+	default:
+		return ""
+	}
+}
+
+// reload .
+func (w *Watcher) reload() error {
+	nv := reflect.New(w.typ)
+	if err := w.decoder.Decode(nv.Interface()); err != nil {
+		return err
+	}
+	if w.options.Validate != nil {
+		if err := w.options.Validate(nv.Interface()); err != nil {
+			return err
+		}
+	}
+
+	old := w.current.Swap(nv.Interface())
+
+	if keys, err := Diff(old, nv.Interface()); err == nil {
+		logger.Infof("[config] reload. changed: %v", keys)
+	}
+
+	if w.onChange != nil {
+		w.onChange(old, nv.Interface())
+	}
+	return nil
+}
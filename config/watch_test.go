@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testWatchConfiguration struct {
+	Name string
+}
+
+// fileDecoder 读取文件内容作为 Name
+type fileDecoder string
+
+func (fd fileDecoder) Decode(v interface{}) error {
+	_, err := fd.DecodeRaw(v)
+	return err
+}
+
+func (fd fileDecoder) DecodeRaw(v interface{}) ([]string, error) {
+	data, err := os.ReadFile(string(fd))
+	if err != nil {
+		return nil, err
+	}
+	v.(*testWatchConfiguration).Name = string(data)
+	return []string{"name"}, nil
+}
+
+func (fd fileDecoder) FilePath() string {
+	return string(fd)
+}
+
+// watchTicker 由 tick 触发轮询
+func watchTicker(tick <-chan time.Time) WatchOption {
+	return func(o *WatchOptions) {
+		o.ticker = tick
+	}
+}
+
+func TestWatch(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(fpath, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var c = new(testWatchConfiguration)
+	if err := fileDecoder(fpath).Decode(c); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		tick    = make(chan time.Time)
+		changes = make(chan [2]string, 1)
+	)
+	w, err := Watch(fileDecoder(fpath), c,
+		func(old, new interface{}) {
+			changes <- [2]string{old.(*testWatchConfiguration).Name, new.(*testWatchConfiguration).Name}
+		},
+		watchTicker(tick),
+		WatchDebounce(0),
+		WatchValidate(func(v interface{}) error {
+			if v.(*testWatchConfiguration).Name == "invalid" {
+				return errors.New("invalid name")
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// poll 发现变化、重新加载。tick 无缓冲，发送完成时上一次轮询已处理完毕
+	poll := func() {
+		for i := 0; i < 3; i++ {
+			tick <- time.Now()
+		}
+	}
+
+	// invalid config is ignored
+	if err := os.WriteFile(fpath, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	poll()
+
+	if name := w.Get().(*testWatchConfiguration).Name; name != "a" {
+		t.Fatalf("expected last good config, got %s", name)
+	}
+
+	if err := os.WriteFile(fpath, []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	poll()
+
+	select {
+	case change := <-changes:
+		if change != [2]string{"a", "b"} {
+			t.Fatalf("unexpected change: %v", change)
+		}
+	default:
+		t.Fatal("expected change")
+	}
+	if name := w.Get().(*testWatchConfiguration).Name; name != "b" || c.Name != "a" {
+		t.Fatalf("unexpected config: %s, initial %s", name, c.Name)
+	}
+}
@@ -15,6 +15,11 @@ type p struct {
 	options *config.Options
 }
 
+func init() {
+	config.Register(".yaml", NewDecoder)
+	config.Register(".yml", NewDecoder)
+}
+
 // NewDecoder .
 func NewDecoder(opts ...config.Option) config.Decoder {
 	var options = new(config.Options)
@@ -31,11 +36,33 @@ func NewDecoder(opts ...config.Option) config.Decoder {
 
 // Decode .
 func (p *p) Decode(v interface{}) error {
-	file, err := os.Open(p.options.FilePath)
-	defer file.Close()
+	if err := config.Defaults(v); err != nil {
+		return err
+	}
+	if _, err := p.DecodeRaw(v); err != nil {
+		return err
+	}
+	return p.options.Complete(v)
+}
 
+// DecodeRaw .
+func (p *p) DecodeRaw(v interface{}) ([]string, error) {
+	data, err := os.ReadFile(p.options.FilePath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
 	}
-	return yaml.NewDecoder(file).Decode(v)
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return config.Keys(v, m), nil
+}
+
+// FilePath .
+func (p *p) FilePath() string {
+	return p.options.FilePath
 }
@@ -1,5 +1,7 @@
 package content
 
+import "strings"
+
 type Type int8
 
 const DefaultContentType Type = Json
@@ -13,10 +15,12 @@ const (
 	Stream
 	FromData
 	Zip
+	Toml
 )
 
 var contents = map[Type]string{
 	Zip:      "application/zip",
+	Toml:     "application/toml",
 	Yaml:     "application/yaml",
 	Text:     "application/text",
 	Json:     "application/json",
@@ -33,3 +37,30 @@ func (t Type) String() string {
 	}
 	return contents[DefaultContentType]
 }
+
+// Parse 解析 content-type，忽略参数(如: charset)
+func Parse(s string) (Type, bool) {
+	if i := strings.Index(s, ";"); i != -1 {
+		s = s[:i]
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	for t, str := range contents {
+		if str == s {
+			return t, true
+		}
+	}
+
+	// 兼容 text/yaml、application/x-yaml 等
+	switch {
+	case strings.HasSuffix(s, "json"):
+		return Json, true
+	case strings.HasSuffix(s, "yaml"), strings.HasSuffix(s, "yml"):
+		return Yaml, true
+	case strings.HasSuffix(s, "toml"):
+		return Toml, true
+	case strings.HasSuffix(s, "protobuf"):
+		return Proto, true
+	}
+	return DefaultContentType, false
+}
@@ -1,7 +1,10 @@
 package database
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 )
 
 var (
@@ -9,29 +12,184 @@ var (
 	ErrorInvaildDsn = errors.New("database: invalid dsn")
 	// ErrorDatabaseNil db is not initialized or closed
 	ErrorDatabaseNil = errors.New("database: db is not initialized or closed")
+	// ErrorDatabaseExists db of the name already registered
+	ErrorDatabaseExists = errors.New("database: db already registered")
 )
 
+// DsnError invalid dsn. errors.Is(err, ErrorInvaildDsn) is true, and Unwrap returns the parse error
+type DsnError struct {
+	// Driver database driver
+	Driver string
+	// Err parse error
+	Err error
+}
+
+// Error .
+func (e *DsnError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%v of %s: %v", ErrorInvaildDsn, e.Driver, e.Err)
+	}
+	return fmt.Sprintf("%v of %s", ErrorInvaildDsn, e.Driver)
+}
+
+// Is .
+func (e *DsnError) Is(target error) bool {
+	return target == ErrorInvaildDsn
+}
+
+// Unwrap .
+func (e *DsnError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectError database connect failed
+type ConnectError struct {
+	// Driver database driver
+	Driver string
+	// Attempts 连接次数
+	Attempts int
+	// Err last error
+	Err error
+}
+
+// Error .
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("database: %s connect failed after %d attempts. %v", e.Driver, e.Attempts, e.Err)
+}
+
+// Unwrap .
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
 type Driver string
 
+// Statement sql 执行信息，用于统计耗时、慢查询等指标
+type Statement struct {
+	// Driver database driver
+	Driver string
+	// SQL 已脱敏(LogRedact)的 sql
+	SQL string
+	// Rows 影响行数
+	Rows int64
+	// Elapsed 耗时
+	Elapsed time.Duration
+	// Slow 是否为慢查询
+	Slow bool
+	// Err error
+	Err error
+}
+
+const (
+	// PolicyRandom 随机选择从库
+	PolicyRandom = "random"
+	// PolicyRoundRobin 轮询从库
+	PolicyRoundRobin = "round-robin"
+	// PolicyLeastLatency 选择 ping 延迟最低的从库
+	PolicyLeastLatency = "least-latency"
+)
+
 // Options .
 type Options struct {
-	// Address address
+	// Address address. 主库地址
 	Address string
+	// Replicas 从库地址
+	Replicas []string
+	// Policy 从库选择策略. PolicyRandom | PolicyRoundRobin | PolicyLeastLatency
+	Policy string
+	// HealthCheckInterval 从库健康检查间隔
+	HealthCheckInterval time.Duration
 	// MaxIdleConns 连接池空闲连接数
 	MaxIdleConns int
 	// MaxOpenConns 连接池最大连接数
 	MaxOpenConns int
-	// ShowSQL 是否显示 sql 日志
+	// ConnMaxLifetime 连接最大复用时间
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime 连接最大空闲时间
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout 连接超时时间
+	ConnectTimeout time.Duration
+	// QueryTimeout sql 执行超时时间。context 已设置 deadline 时不生效
+	QueryTimeout time.Duration
+	// PrepareStmt 缓存预编译语句
+	PrepareStmt bool
+	// ShowSQL 是否显示 sql 日志。为 false 时仅输出慢查询及错误
 	ShowSQL bool
+	// SlowThreshold 慢查询阈值，超过时总是输出日志。为 0 时不记录慢查询
+	SlowThreshold time.Duration
+	// LogSample 普通 sql 日志的采样率(0, 1]，慢查询及错误不采样
+	LogSample float64
+	// LogRedact 日志中隐藏 sql 参数
+	LogRedact bool
+	// LogMetadata 日志中输出的 context 中的 metadata.Metadata 字段，为空时不输出。避免输出 token 等敏感字段
+	LogMetadata []string
+	// Observer 每条 sql 执行后调用，用于上报指标
+	Observer func(ctx context.Context, stmt *Statement)
+	// AuditKey 操作人在 context 中 metadata.Metadata 的 key，用于填充 created_by、updated_by
+	AuditKey string
+	// TenantKey 租户在 context 中 metadata.Metadata 的 key，为空时不启用多租户
+	TenantKey string
+	// TenantSchema 租户 schema 名称格式，如: tenant_%v。为空时使用 tenant_id 列隔离
+	TenantSchema string
+	// Retry 连接失败时的重试次数
+	Retry int
+	// RetryBackoff 首次重试的等待时间，之后每次翻倍，最大为 maxRetryBackoff
+	RetryBackoff time.Duration
 }
 
+const (
+	// defaultMaxIdleConns 默认连接池空闲连接数
+	defaultMaxIdleConns = 10
+	// defaultMaxOpenConns 默认连接池最大连接数
+	defaultMaxOpenConns = 100
+	// defaultConnMaxLifetime 默认连接最大复用时间
+	defaultConnMaxLifetime = time.Hour
+	// defaultConnectTimeout 默认连接超时时间
+	defaultConnectTimeout = 10 * time.Second
+	// defaultHealthCheckInterval 默认从库健康检查间隔
+	defaultHealthCheckInterval = 10 * time.Second
+	// defaultSlowThreshold 默认慢查询阈值
+	defaultSlowThreshold = 200 * time.Millisecond
+	// defaultAuditKey 默认操作人 key
+	defaultAuditKey = "user"
+	// defaultRetryBackoff 默认重试等待时间
+	defaultRetryBackoff = time.Second
+	// maxRetryBackoff 最大重试等待时间
+	maxRetryBackoff = 30 * time.Second
+)
+
 // DefaultOptions .
 func DefaultOptions() *Options {
 	return &Options{
-		ShowSQL: false,
+		MaxIdleConns:        defaultMaxIdleConns,
+		MaxOpenConns:        defaultMaxOpenConns,
+		ConnMaxLifetime:     defaultConnMaxLifetime,
+		ConnectTimeout:      defaultConnectTimeout,
+		ShowSQL:             false,
+		SlowThreshold:       defaultSlowThreshold,
+		LogSample:           1,
+		AuditKey:            defaultAuditKey,
+		Policy:              PolicyRandom,
+		HealthCheckInterval: defaultHealthCheckInterval,
+		RetryBackoff:        defaultRetryBackoff,
 	}
 }
 
+// Backoff 第 attempt(从 1 开始)次重试前的等待时间
+func (opts *Options) Backoff(attempt int) time.Duration {
+	d := opts.RetryBackoff
+	if d <= 0 {
+		d = defaultRetryBackoff
+	}
+	for i := 1; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d
+}
+
 type Option func(opts *Options)
 
 // Address .
@@ -41,9 +199,161 @@ func Address(address string) Option {
 	}
 }
 
+// Addrs 第一个地址为主库，其余为从库。如: server.Database.Addrs
+func Addrs(addrs ...string) Option {
+	return func(opts *Options) {
+		if len(addrs) != 0 {
+			opts.Address = addrs[0]
+			opts.Replicas = addrs[1:]
+		}
+	}
+}
+
+// Replicas .
+func Replicas(addrs ...string) Option {
+	return func(opts *Options) {
+		opts.Replicas = addrs
+	}
+}
+
+// Policy .
+func Policy(policy string) Option {
+	return func(opts *Options) {
+		opts.Policy = policy
+	}
+}
+
+// HealthCheckInterval .
+func HealthCheckInterval(d time.Duration) Option {
+	return func(opts *Options) {
+		if d > 0 {
+			opts.HealthCheckInterval = d
+		}
+	}
+}
+
+// MaxIdleConns .
+func MaxIdleConns(n int) Option {
+	return func(opts *Options) {
+		opts.MaxIdleConns = n
+	}
+}
+
+// MaxOpenConns .
+func MaxOpenConns(n int) Option {
+	return func(opts *Options) {
+		opts.MaxOpenConns = n
+	}
+}
+
+// ConnMaxLifetime .
+func ConnMaxLifetime(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.ConnMaxLifetime = d
+	}
+}
+
+// ConnMaxIdleTime .
+func ConnMaxIdleTime(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.ConnMaxIdleTime = d
+	}
+}
+
+// ConnectTimeout .
+func ConnectTimeout(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.ConnectTimeout = d
+	}
+}
+
+// QueryTimeout .
+func QueryTimeout(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.QueryTimeout = d
+	}
+}
+
+// PrepareStmt .
+func PrepareStmt(b bool) Option {
+	return func(opts *Options) {
+		opts.PrepareStmt = b
+	}
+}
+
 // ShowSQL .
 func ShowSQL(b bool) Option {
 	return func(opts *Options) {
 		opts.ShowSQL = b
 	}
 }
+
+// SlowThreshold .
+func SlowThreshold(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.SlowThreshold = d
+	}
+}
+
+// LogSample .
+func LogSample(rate float64) Option {
+	return func(opts *Options) {
+		if rate > 0 && rate <= 1 {
+			opts.LogSample = rate
+		}
+	}
+}
+
+// LogRedact .
+func LogRedact(b bool) Option {
+	return func(opts *Options) {
+		opts.LogRedact = b
+	}
+}
+
+// LogMetadata .
+func LogMetadata(keys ...string) Option {
+	return func(opts *Options) {
+		opts.LogMetadata = keys
+	}
+}
+
+// Observer .
+func Observer(fn func(ctx context.Context, stmt *Statement)) Option {
+	return func(opts *Options) {
+		opts.Observer = fn
+	}
+}
+
+// AuditKey .
+func AuditKey(key string) Option {
+	return func(opts *Options) {
+		if len(key) != 0 {
+			opts.AuditKey = key
+		}
+	}
+}
+
+// TenantKey .
+func TenantKey(key string) Option {
+	return func(opts *Options) {
+		opts.TenantKey = key
+	}
+}
+
+// TenantSchema .
+func TenantSchema(format string) Option {
+	return func(opts *Options) {
+		opts.TenantSchema = format
+	}
+}
+
+// Retry 连接失败时重试 n 次，每次等待时间翻倍
+func Retry(n int, backoff time.Duration) Option {
+	return func(opts *Options) {
+		opts.Retry = n
+		if backoff > 0 {
+			opts.RetryBackoff = backoff
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+
+	"github.com/charlesbases/hfw/xhttp/webcode"
+)
+
+var (
+	// ErrorNotFound record not found
+	ErrorNotFound = errors.New("database: record not found")
+	// ErrorUniqueViolation 唯一约束冲突
+	ErrorUniqueViolation = errors.New("database: unique violation")
+	// ErrorForeignKeyViolation 外键约束冲突
+	ErrorForeignKeyViolation = errors.New("database: foreign key violation")
+	// ErrorCheckViolation check 约束冲突
+	ErrorCheckViolation = errors.New("database: check violation")
+	// ErrorTimeout 执行超时
+	ErrorTimeout = errors.New("database: timeout")
+	// ErrorConnectionLost 连接断开
+	ErrorConnectionLost = errors.New("database: connection lost")
+)
+
+// Error 分类后的数据库错误。errors.Is(err, Kind) 为 true，errors.As 可获取驱动的原始错误
+type Error struct {
+	// Kind ErrorNotFound | ErrorUniqueViolation | ErrorForeignKeyViolation | ErrorCheckViolation | ErrorTimeout | ErrorConnectionLost
+	Kind error
+	// Constraint 冲突的约束名称(仅 postgres)
+	Constraint string
+	// Err 原始错误
+	Err error
+}
+
+// Error .
+func (e *Error) Error() string {
+	if len(e.Constraint) != 0 {
+		return fmt.Sprintf("%v(%s). %v", e.Kind, e.Constraint, e.Err)
+	}
+	return fmt.Sprintf("%v. %v", e.Kind, e.Err)
+}
+
+// Is .
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap .
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classifier 驱动错误的分类，由驱动包(如: orm/driver)在 init 中注册
+type Classifier interface {
+	// Classify 返回错误类型(ErrorNotFound 等)及冲突的约束名称，无法识别时返回 nil
+	Classify(err error) (error, string)
+	// Retryable 死锁、序列化失败等重新执行事务即可能成功的错误
+	Retryable(err error) bool
+}
+
+var (
+	mu          sync.RWMutex
+	classifiers = make([]Classifier, 0)
+)
+
+// RegisterClassifier .
+func RegisterClassifier(c Classifier) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	classifiers = append(classifiers, c)
+}
+
+// registered .
+func registered() []Classifier {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return classifiers
+}
+
+// Classify 识别数据库错误，返回 *Error。驱动的错误由注册的 Classifier 识别
+// 无法识别时返回原错误
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	if kind, constraint := classify(err); kind != nil {
+		return &Error{Kind: kind, Constraint: constraint, Err: err}
+	}
+	return err
+}
+
+// classify .
+func classify(err error) (error, string) {
+	for _, c := range registered() {
+		if kind, constraint := c.Classify(err); kind != nil {
+			return kind, constraint
+		}
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrorNotFound, ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorTimeout, ""
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE):
+		return ErrorConnectionLost, ""
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorTimeout, ""
+		}
+		return ErrorConnectionLost, ""
+	}
+	return nil, ""
+}
+
+// Retryable 死锁、序列化失败等重新执行事务即可能成功的错误，由注册的 Classifier 识别
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, c := range registered() {
+		if c.Retryable(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebError 将数据库错误转换为 webcode.Error，不返回驱动的错误信息
+// 实现 Code() webcode.Code 的错误(如 orm.ConcurrentModificationError)使用其错误码
+func WebError(err error) *webcode.Error {
+	if err == nil {
+		return nil
+	}
+
+	var coder interface{ Code() webcode.Code }
+	if errors.As(err, &coder) {
+		return webcode.NewError(coder.Code(), coder.Code())
+	}
+
+	var code = webcode.DatabaseErr
+	switch err := Classify(err); {
+	case errors.Is(err, ErrorNotFound):
+		code = webcode.DataNotFound
+	case errors.Is(err, ErrorUniqueViolation):
+		code = webcode.DataExists
+	case errors.Is(err, ErrorForeignKeyViolation):
+		code = webcode.DataReferenced
+	case errors.Is(err, ErrorCheckViolation):
+		code = webcode.ParamInvalid
+	case errors.Is(err, ErrorTimeout):
+		code = webcode.ServiceTimeout
+	case errors.Is(err, ErrorConnectionLost):
+		code = webcode.ServiceUnreachable
+	}
+	return webcode.NewError(code, code)
+}
@@ -0,0 +1,81 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/ormtest"
+	"github.com/charlesbases/hfw/xhttp/webcode"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestClassify(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		kind error
+		code webcode.Code
+	}{
+		{gorm.ErrRecordNotFound, database.ErrorNotFound, webcode.DataNotFound},
+		{&mysqldriver.MySQLError{Number: 1062}, database.ErrorUniqueViolation, webcode.DataExists},
+		{&mysqldriver.MySQLError{Number: 1452}, database.ErrorForeignKeyViolation, webcode.DataReferenced},
+		{&mysqldriver.MySQLError{Number: 2013}, database.ErrorConnectionLost, webcode.ServiceUnreachable},
+		{&pgconn.PgError{Code: "23514"}, database.ErrorCheckViolation, webcode.ParamInvalid},
+		{&pgconn.PgError{Code: "08006"}, database.ErrorConnectionLost, webcode.ServiceUnreachable},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), database.ErrorTimeout, webcode.ServiceTimeout},
+		{errors.New("unknown"), nil, webcode.DatabaseErr},
+	} {
+		err := database.Classify(c.err)
+		if c.kind != nil && !errors.Is(err, c.kind) {
+			t.Fatalf("%v: expected %v, got %v", c.err, c.kind, err)
+		}
+		if !errors.Is(err, c.err) {
+			t.Fatalf("%v: expected wraps the original error", c.err)
+		}
+		if e := database.WebError(c.err); e.Code != c.code.Int32() {
+			t.Fatalf("%v: expected code %d, got %d", c.err, c.code, e.Code)
+		}
+	}
+
+	if err := database.Classify(nil); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if !database.Retryable(&mysqldriver.MySQLError{Number: 1213}) || database.Retryable(&mysqldriver.MySQLError{Number: 1062}) {
+		t.Fatal("unexpected retryable")
+	}
+}
+
+func TestClassifySQLite(t *testing.T) {
+	db := ormtest.Open(t, "foreign_keys(1)")
+
+	for _, sql := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT UNIQUE, age INTEGER CHECK (age >= 0))",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id))",
+		"INSERT INTO users (id, name, age) VALUES (1, 'a', 1)",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for sql, kind := range map[string]error{
+		"INSERT INTO users (name, age) VALUES ('a', 1)":  database.ErrorUniqueViolation,
+		"INSERT INTO users (id, name) VALUES (1, 'b')":   database.ErrorUniqueViolation,
+		"INSERT INTO users (name, age) VALUES ('c', -1)": database.ErrorCheckViolation,
+		"INSERT INTO posts (user_id) VALUES (2)":         database.ErrorForeignKeyViolation,
+	} {
+		if err := db.Exec(sql).Error; !errors.Is(database.Classify(err), kind) {
+			t.Fatalf("%s: expected %v, got %v", sql, kind, err)
+		}
+	}
+
+	var user = make(map[string]interface{})
+	err := db.Table("users").Where("id = ?", 2).Take(&user).Error
+	if !errors.Is(database.Classify(err), database.ErrorNotFound) {
+		t.Fatalf("expected ErrorNotFound, got %v", err)
+	}
+}
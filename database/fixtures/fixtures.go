@@ -0,0 +1,313 @@
+// Package fixtures 测试数据加载
+//
+// fixture 文件为 YAML 或 JSON，key 为表名，value 为记录列表或 {depends, rows}:
+//
+//	users:
+//	  - id: 1
+//	    name: alice
+//	    created_at: '{{ now }}'
+//	posts:
+//	  depends: [users]
+//	  rows:
+//	    - id: 1
+//	      user_id: 1
+//	      created_at: '{{ ago "24h" }}'
+//
+// 文件内容先作为 text/template 渲染，可用函数见 Funcs
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/charlesbases/hfw/codec"
+	"github.com/charlesbases/hfw/codec/json"
+	"github.com/charlesbases/hfw/codec/yaml"
+	"github.com/charlesbases/hfw/database/orm"
+	"github.com/charlesbases/hfw/xtime"
+	"gorm.io/gorm"
+)
+
+// Options .
+type Options struct {
+	// Funcs 模板函数，覆盖同名的默认函数
+	Funcs template.FuncMap
+}
+
+type Option func(o *Options)
+
+// Funcs .
+func Funcs(funcs template.FuncMap) Option {
+	return func(o *Options) {
+		o.Funcs = funcs
+	}
+}
+
+// defaultFuncs 默认模板函数
+//   - now: 当前时间，xtime.DefaultLayout 格式
+//   - timestamp: 当前毫秒时间戳
+//   - ago "24h": 当前时间之前
+//   - later "24h": 当前时间之后
+func defaultFuncs() template.FuncMap {
+	return template.FuncMap{
+		"now":       xtime.Now,
+		"timestamp": xtime.NowTimestamp,
+		"ago": func(s string) (string, error) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return "", err
+			}
+			return xtime.Format(time.Now().Add(-d)), nil
+		},
+		"later": func(s string) (string, error) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return "", err
+			}
+			return xtime.Format(time.Now().Add(d)), nil
+		},
+	}
+}
+
+// table .
+type table struct {
+	name    string
+	depends []string
+	rows    []map[string]interface{}
+}
+
+// Fixtures .
+type Fixtures struct {
+	tables map[string]*table
+}
+
+// Load 加载 fsys 中匹配 patterns 的 fixture 文件，如: Load(os.DirFS("testdata"), "*.yaml")
+// 同一张表出现在多个文件中时，记录按文件名顺序合并
+func Load(fsys fs.FS, patterns []string, opts ...Option) (*Fixtures, error) {
+	var options = new(Options)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var funcs = defaultFuncs()
+	for name, fn := range options.Funcs {
+		funcs[name] = fn
+	}
+
+	var files = make([]string, 0)
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var f = &Fixtures{tables: make(map[string]*table)}
+	for _, file := range files {
+		if err := f.load(fsys, file, funcs); err != nil {
+			return nil, fmt.Errorf("fixtures: %s: %v", file, err)
+		}
+	}
+	return f, nil
+}
+
+// marshaler .
+func marshaler(file string) (codec.Marshaler, error) {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".yaml", ".yml":
+		return yaml.DefaultMarshaler, nil
+	case ".json":
+		return json.DefaultMarshaler, nil
+	default:
+		return nil, fmt.Errorf("unsupported fixture file")
+	}
+}
+
+// load .
+func (f *Fixtures) load(fsys fs.FS, file string, funcs template.FuncMap) error {
+	m, err := marshaler(file)
+	if err != nil {
+		return err
+	}
+
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New(file).Funcs(funcs).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return err
+	}
+
+	var doc = make(map[string]interface{})
+	if err := m.Unmarshal(buf.Bytes(), &doc); err != nil {
+		return err
+	}
+
+	for name, val := range doc {
+		t, found := f.tables[name]
+		if !found {
+			t = &table{name: name}
+			f.tables[name] = t
+		}
+
+		var rows interface{}
+		switch v := val.(type) {
+		case []interface{}:
+			rows = v
+		case map[string]interface{}:
+			if depends, found := v["depends"]; found {
+				list, ok := depends.([]interface{})
+				if !ok {
+					return fmt.Errorf("depends of %s must be a list", name)
+				}
+				for _, item := range list {
+					t.depends = append(t.depends, fmt.Sprint(item))
+				}
+			}
+			rows = v["rows"]
+		default:
+			return fmt.Errorf("invalid fixture of %s", name)
+		}
+
+		if rows == nil {
+			continue
+		}
+		list, ok := rows.([]interface{})
+		if !ok {
+			return fmt.Errorf("rows of %s must be a list", name)
+		}
+		for _, item := range list {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid row of %s", name)
+			}
+			t.rows = append(t.rows, normalize(row))
+		}
+	}
+	return nil
+}
+
+// normalize json 中的整数解析为 float64，还原为 int64
+func normalize(row map[string]interface{}) map[string]interface{} {
+	for key, val := range row {
+		if f, ok := val.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			row[key] = int64(f)
+		}
+	}
+	return row
+}
+
+// order 按依赖排序，无依赖关系的表按表名排序
+func (f *Fixtures) order() ([]*table, error) {
+	var names = make([]string, 0, len(f.tables))
+	for name := range f.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		ordered = make([]*table, 0, len(names))
+		// state 0: 未访问 1: 访问中 2: 已完成
+		state = make(map[string]int, len(names))
+		visit func(name string, path []string) error
+	)
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("fixtures: circular dependency %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+
+		t, found := f.tables[name]
+		if !found {
+			return fmt.Errorf("fixtures: unknown dependency %s of %s", name, path[len(path)-1])
+		}
+
+		state[name] = 1
+		depends := append([]string(nil), t.depends...)
+		sort.Strings(depends)
+		for _, depend := range depends {
+			if err := visit(depend, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		ordered = append(ordered, t)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Tables 按依赖排序的表名
+func (f *Fixtures) Tables() ([]string, error) {
+	tables, err := f.order()
+	if err != nil {
+		return nil, err
+	}
+
+	var names = make([]string, 0, len(tables))
+	for _, t := range tables {
+		names = append(names, t.name)
+	}
+	return names, nil
+}
+
+// Insert 按依赖顺序写入数据
+func (f *Fixtures) Insert(db *gorm.DB) error {
+	tables, err := f.order()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tables {
+		for _, row := range t.rows {
+			if err := db.Table(t.name).Create(row).Error; err != nil {
+				return fmt.Errorf("fixtures: insert into %s failed. %v", t.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Setup 开启事务并写入数据，测试结束时回滚
+// 返回的 ctx 中保存了该事务，db 的 orm.Tx、orm.Conn、orm.Repository 均在该事务中执行
+func Setup(t testing.TB, db *gorm.DB, f *Fixtures) (context.Context, *gorm.DB) {
+	t.Helper()
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("fixtures: begin failed. %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+	})
+
+	if err := f.Insert(tx); err != nil {
+		t.Fatal(err)
+	}
+	return orm.WithTx(context.Background(), tx), tx
+}
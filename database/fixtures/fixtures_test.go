@@ -0,0 +1,98 @@
+package fixtures
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/charlesbases/hfw/database/orm"
+	"github.com/charlesbases/hfw/database/orm/ormtest"
+	"github.com/charlesbases/hfw/xtime"
+)
+
+var testdata = fstest.MapFS{
+	"posts.yaml": {Data: []byte(`
+posts:
+  depends: [users]
+  rows:
+    - id: 1
+      user_id: 1
+      created_at: '{{ ago "24h" }}'
+    - id: 2
+      user_id: 2
+      created_at: '{{ now }}'
+`)},
+	"users.json": {Data: []byte(`{
+  "users": [
+    {"id": 1, "name": "alice"},
+    {"id": 2, "name": "{{ name }}"}
+  ]
+}`)},
+}
+
+type Post struct {
+	ID        int64
+	UserID    int64
+	CreatedAt string
+}
+
+func TestFixtures(t *testing.T) {
+	db := ormtest.Open(t, "foreign_keys(1)")
+
+	for _, sql := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), created_at TEXT)",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := Load(testdata, []string{"*.yaml", "*.json"}, Funcs(map[string]interface{}{
+		"name": func() string { return "bob" },
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables, _ := f.Tables(); !reflect.DeepEqual(tables, []string{"users", "posts"}) {
+		t.Fatalf("unexpected tables: %v", tables)
+	}
+
+	t.Run("setup", func(t *testing.T) {
+		ctx, _ := Setup(t, db, f)
+
+		var names []string
+		orm.Conn(ctx, db).Table("users").Order("id").Pluck("name", &names)
+		if !reflect.DeepEqual(names, []string{"alice", "bob"}) {
+			t.Fatalf("unexpected users: %v", names)
+		}
+
+		posts, err := orm.NewRepository[Post](db).List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 2 || !xtime.Parse(posts[0].CreatedAt).Before(xtime.Parse(posts[1].CreatedAt)) {
+			t.Fatalf("unexpected posts: %+v", posts)
+		}
+	})
+
+	// 测试结束后回滚
+	var count int64
+	db.Table("users").Count(&count)
+	if count != 0 {
+		t.Fatalf("expected rollback, got %d users", count)
+	}
+}
+
+func TestFixturesCircular(t *testing.T) {
+	f, err := Load(fstest.MapFS{"a.yaml": {Data: []byte(`
+a: {depends: [b], rows: []}
+b: {depends: [a], rows: []}
+`)}}, []string{"*.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Tables(); err == nil {
+		t.Fatal("expected circular dependency error")
+	}
+}
@@ -0,0 +1,138 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/charlesbases/logger"
+	"gorm.io/gorm"
+)
+
+// lockRetryInterval 锁表重试间隔
+const lockRetryInterval = 100 * time.Millisecond
+
+// lock 获取数据库锁，防止多个实例同时执行 migration
+// mysql 使用 GET_LOCK，postgres 使用 pg_advisory_lock，其他数据库使用锁表
+func (m *Migrator) lock(ctx context.Context, db *gorm.DB) (func(), error) {
+	switch db.Dialector.Name() {
+	case "mysql", "postgres":
+		return m.advisoryLock(ctx, db)
+	default:
+		return m.tableLock(ctx, db)
+	}
+}
+
+// advisoryLock 会话级锁，加锁与解锁需使用同一连接
+func (m *Migrator) advisoryLock(ctx context.Context, db *gorm.DB) (func(), error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock, unlock string
+	var args []interface{}
+	if db.Dialector.Name() == "mysql" {
+		lock, unlock = "SELECT GET_LOCK(?, ?)", "SELECT RELEASE_LOCK(?)"
+		args = []interface{}{m.options.Table, int(m.options.LockTimeout.Seconds())}
+	} else {
+		h := fnv.New64a()
+		h.Write([]byte(m.options.Table))
+		lock, unlock = "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)"
+		args = []interface{}{int64(h.Sum64())}
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, m.options.LockTimeout)
+	defer cancel()
+
+	var acquired sql.NullInt64
+	if db.Dialector.Name() == "mysql" {
+		err = conn.QueryRowContext(lockCtx, lock, args...).Scan(&acquired)
+	} else {
+		_, err = conn.ExecContext(lockCtx, lock, args...)
+		acquired.Int64 = 1
+	}
+	if err != nil {
+		conn.Close()
+		if lockCtx.Err() != nil {
+			return nil, ErrLockTimeout
+		}
+		return nil, err
+	}
+	if acquired.Int64 != 1 {
+		conn.Close()
+		return nil, ErrLockTimeout
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), unlock, args[0]); err != nil {
+			logger.Errorf("[migrate] release lock failed. %v", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+// tableLock 使用锁表中的唯一记录作为锁
+func (m *Migrator) tableLock(ctx context.Context, db *gorm.DB) (func(), error) {
+	table := m.options.Table + "_lock"
+	if err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY, locked_at BIGINT NOT NULL)", db.Statement.Quote(table))).Error; err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(m.options.LockTimeout)
+	for {
+		// 清理过期的锁
+		db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = 1 AND locked_at < ?", db.Statement.Quote(table)), time.Now().Add(-m.options.LockExpire).UnixMilli())
+
+		if err := db.Exec(fmt.Sprintf("INSERT INTO %s (id, locked_at) VALUES (1, ?)", db.Statement.Quote(table)), time.Now().UnixMilli()).Error; err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	// 持有锁期间续期，防止执行时间较长的 migration 的锁过期后被其他实例获取
+	var (
+		stop = make(chan struct{})
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(m.options.LockExpire / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := db.Exec(fmt.Sprintf("UPDATE %s SET locked_at = ? WHERE id = 1", db.Statement.Quote(table)), time.Now().UnixMilli()).Error; err != nil {
+					logger.Errorf("[migrate] renew lock failed. %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+
+		if err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = 1", db.Statement.Quote(table))).Error; err != nil {
+			logger.Errorf("[migrate] release lock failed. %v", err)
+		}
+	}, nil
+}
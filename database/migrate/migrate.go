@@ -0,0 +1,438 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charlesbases/hfw/database/orm"
+	"github.com/charlesbases/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultTable 默认版本记录表
+	defaultTable = "schema_migrations"
+	// defaultLockTimeout 默认获取锁的超时时间
+	defaultLockTimeout = time.Minute
+	// defaultLockExpire 默认锁表中的锁过期时间
+	defaultLockExpire = 10 * time.Minute
+)
+
+var (
+	// ErrDuplicateVersion duplicate migration version
+	ErrDuplicateVersion = errors.New("migrate: duplicate version")
+	// ErrIrreversible migration without down
+	ErrIrreversible = errors.New("migrate: irreversible migration")
+	// ErrLockTimeout acquire lock timeout
+	ErrLockTimeout = errors.New("migrate: acquire lock timeout")
+)
+
+// sqlFileRegexp 0001_create_users.up.sql
+var sqlFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration .
+type Migration struct {
+	// Version 版本号，按从小到大的顺序执行
+	Version int64
+	// Name .
+	Name string
+	// Up .
+	Up func(tx *gorm.DB) error
+	// Down 为 nil 时不可回滚
+	Down func(tx *gorm.DB) error
+}
+
+// String .
+func (m *Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+// Status .
+type Status struct {
+	*Migration
+	// Applied 是否已执行
+	Applied bool
+	// AppliedAt 执行时间
+	AppliedAt time.Time
+}
+
+// record 版本记录
+type record struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// Options .
+type Options struct {
+	// Table 版本记录表
+	Table string
+	// DryRun 仅输出待执行的 migration，不执行、不加锁、不创建版本记录表
+	DryRun bool
+	// LockTimeout 获取锁的超时时间
+	LockTimeout time.Duration
+	// LockExpire 锁表中的锁过期时间，防止进程退出后锁未释放。持有锁期间定时续期
+	// mysql、postgres 使用会话级锁，不生效
+	LockExpire time.Duration
+}
+
+type Option func(o *Options)
+
+// Table .
+func Table(name string) Option {
+	return func(o *Options) {
+		o.Table = name
+	}
+}
+
+// DryRun .
+func DryRun(b bool) Option {
+	return func(o *Options) {
+		o.DryRun = b
+	}
+}
+
+// LockTimeout .
+func LockTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		if d > 0 {
+			o.LockTimeout = d
+		}
+	}
+}
+
+// LockExpire .
+func LockExpire(d time.Duration) Option {
+	return func(o *Options) {
+		if d > 0 {
+			o.LockExpire = d
+		}
+	}
+}
+
+// Migrator .
+type Migrator struct {
+	db         *gorm.DB
+	options    *Options
+	migrations map[int64]*Migration
+}
+
+// New .
+func New(db *gorm.DB, opts ...Option) *Migrator {
+	var options = &Options{Table: defaultTable, LockTimeout: defaultLockTimeout, LockExpire: defaultLockExpire}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &Migrator{db: db, options: options, migrations: make(map[int64]*Migration)}
+}
+
+// Add 添加 migration
+func (m *Migrator) Add(migrations ...*Migration) error {
+	for _, migration := range migrations {
+		if _, found := m.migrations[migration.Version]; found {
+			return fmt.Errorf("%w: %d", ErrDuplicateVersion, migration.Version)
+		}
+		if migration.Up == nil {
+			return fmt.Errorf("migrate: %s without up", migration)
+		}
+		m.migrations[migration.Version] = migration
+	}
+	return nil
+}
+
+// AddFS 添加 dir 下的 sql 文件，如: embed.FS
+// 文件名格式: <version>_<name>.up.sql、<version>_<name>.down.sql
+func (m *Migrator) AddFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	var migrations = make(map[int64]*Migration)
+	for _, entry := range entries {
+		matches := sqlFileRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migrate: invalid version of %s. %v", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		migration, found := migrations[version]
+		if !found {
+			migration = &Migration{Version: version, Name: matches[2]}
+			migrations[version] = migration
+		}
+
+		if matches[3] == "up" {
+			migration.Up = execSQL(string(data))
+		} else {
+			migration.Down = execSQL(string(data))
+		}
+	}
+
+	for _, migration := range migrations {
+		if err := m.Add(migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status 所有 migration 的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	db := m.session(ctx)
+
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var status = make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.sorted() {
+		r, found := applied[migration.Version]
+		s := &Status{Migration: migration, Applied: found}
+		if found {
+			s.AppliedAt = r.AppliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// Up 按版本顺序执行所有未执行的 migration，返回执行(DryRun 时为待执行)的 migration
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	return m.To(ctx, -1)
+}
+
+// To 迁移到 version: 执行版本号 <= version 的未执行 migration，回滚版本号 > version 的已执行 migration
+// version 为 -1 时执行所有 migration，为 0 时回滚所有 migration
+func (m *Migrator) To(ctx context.Context, version int64) ([]*Migration, error) {
+	db := m.session(ctx)
+
+	if !m.options.DryRun {
+		unlock, err := m.lock(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		if err := m.prepare(db); err != nil {
+			return nil, err
+		}
+	}
+
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ups, downs = make([]*Migration, 0), make([]*Migration, 0)
+	for _, migration := range m.sorted() {
+		_, found := applied[migration.Version]
+		switch {
+		case !found && (version < 0 || migration.Version <= version):
+			ups = append(ups, migration)
+		case found && version >= 0 && migration.Version > version:
+			downs = append([]*Migration{migration}, downs...)
+		}
+	}
+
+	// 回滚前检查，避免回滚一半
+	for _, migration := range downs {
+		if migration.Down == nil {
+			return nil, fmt.Errorf("%w: %s", ErrIrreversible, migration)
+		}
+	}
+
+	var done = make([]*Migration, 0, len(ups)+len(downs))
+	for _, migration := range downs {
+		if err := m.down(db, migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	for _, migration := range ups {
+		if err := m.up(db, migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Rollback 回滚版本号 > version 的已执行 migration
+func (m *Migrator) Rollback(ctx context.Context, version int64) ([]*Migration, error) {
+	if version < 0 {
+		version = 0
+	}
+	return m.To(ctx, version)
+}
+
+// up .
+func (m *Migrator) up(db *gorm.DB, migration *Migration) error {
+	if m.options.DryRun {
+		logger.Infof("[migrate] up %s (dry run)", migration)
+		return nil
+	}
+
+	logger.Infof("[migrate] up %s", migration)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Up(tx); err != nil {
+			return fmt.Errorf("migrate: up %s failed. %w", migration, err)
+		}
+		return tx.Table(m.options.Table).Create(&record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+	})
+}
+
+// down .
+func (m *Migrator) down(db *gorm.DB, migration *Migration) error {
+	if m.options.DryRun {
+		logger.Infof("[migrate] down %s (dry run)", migration)
+		return nil
+	}
+
+	logger.Infof("[migrate] down %s", migration)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return fmt.Errorf("migrate: down %s failed. %w", migration, err)
+		}
+		return tx.Table(m.options.Table).Where("version = ?", migration.Version).Delete(&record{}).Error
+	})
+}
+
+// session 使用主库
+func (m *Migrator) session(ctx context.Context) *gorm.DB {
+	return m.db.WithContext(orm.WithPrimary(ctx))
+}
+
+// prepare 创建版本记录表
+func (m *Migrator) prepare(db *gorm.DB) error {
+	return db.Table(m.options.Table).AutoMigrate(&record{})
+}
+
+// applied 已执行的 migration。版本记录表不存在时视为均未执行
+func (m *Migrator) applied(db *gorm.DB) (map[int64]*record, error) {
+	if !db.Migrator().HasTable(m.options.Table) {
+		return make(map[int64]*record), nil
+	}
+
+	var records []*record
+	if err := db.Table(m.options.Table).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	var applied = make(map[int64]*record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// sorted .
+func (m *Migrator) sorted() []*Migration {
+	var migrations = make([]*Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations
+}
+
+// execSQL 依次执行 sql 中的语句
+func execSQL(sql string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, stmt := range split(sql) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// split 按 ';' 拆分语句，忽略引号、postgres 美元引号($$、$body$)及注释中的 ';'。注释不包含在语句中
+func split(sql string) []string {
+	var (
+		stmts = make([]string, 0)
+		b     strings.Builder
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(b.String()); len(stmt) != 0 {
+			stmts = append(stmts, stmt)
+		}
+		b.Reset()
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			n := len(sql) - i
+			if end := strings.IndexByte(sql[i+1:], c); end != -1 {
+				n = end + 2
+			}
+			b.WriteString(sql[i : i+n])
+			i += n
+		case c == '$' && len(dollarTag(sql[i:])) != 0:
+			tag := dollarTag(sql[i:])
+			n := len(sql) - i
+			if end := strings.Index(sql[i+len(tag):], tag); end != -1 {
+				n = len(tag) + end + len(tag)
+			}
+			b.WriteString(sql[i : i+n])
+			i += n
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end == -1 {
+				end = len(sql) - i
+			}
+			i += end
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				end = len(sql) - i - 4
+			}
+			i += end + 4
+		case c == ';':
+			flush()
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return stmts
+}
+
+// dollarTag postgres 美元引号的标签，如: $$、$body$。s 不以标签开头(如: $1)时返回空字符串
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '$':
+			return s[:i+1]
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', i > 1 && '0' <= c && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/charlesbases/hfw/database/orm/ormtest"
+	"gorm.io/gorm"
+)
+
+var testdata = fstest.MapFS{
+	"migrations/0001_create_users.up.sql": {Data: []byte(`
+-- users
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT ';');
+INSERT INTO users (name) VALUES ('a;b');
+`)},
+	"migrations/0001_create_users.down.sql": {Data: []byte(`DROP TABLE users;`)},
+	"migrations/0002_create_roles.up.sql":   {Data: []byte(`CREATE TABLE roles (id INTEGER PRIMARY KEY);`)},
+	"migrations/0002_create_roles.down.sql": {Data: []byte(`DROP TABLE roles;`)},
+}
+
+// open .
+func open(t *testing.T) *gorm.DB {
+	db := ormtest.Open(t, "busy_timeout(5000)")
+	return db
+}
+
+// newMigrator .
+func newMigrator(t *testing.T, db *gorm.DB, opts ...Option) *Migrator {
+	m := New(db, opts...)
+	if err := m.AddFS(testdata, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add(&Migration{
+		Version: 3,
+		Name:    "add_users_email",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("ALTER TABLE users ADD COLUMN email TEXT").Error
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMigrate(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = open(t)
+	)
+
+	// dry run
+	{
+		done, err := newMigrator(t, db, DryRun(true)).Up(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(done) != 3 || db.Migrator().HasTable("users") {
+			t.Fatalf("unexpected dry run: %v", done)
+		}
+		// dry run 不修改数据库
+		if tables, _ := db.Migrator().GetTables(); len(tables) != 0 {
+			t.Fatalf("unexpected tables after dry run: %v", tables)
+		}
+	}
+
+	m := newMigrator(t, db)
+	if done, err := m.Up(ctx); err != nil || len(done) != 3 {
+		t.Fatalf("unexpected up: %v %v", done, err)
+	}
+	if !db.Migrator().HasColumn("users", "email") {
+		t.Fatal("expected users.email")
+	}
+
+	var name string
+	db.Raw("SELECT name FROM users").Scan(&name)
+	if name != "a;b" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+
+	// 不可回滚
+	if _, err := m.Rollback(ctx, 1); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("expected ErrIrreversible, got %v", err)
+	}
+
+	// 回滚到版本 2 后再回滚到 1
+	m.migrations[3].Down = func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE users DROP COLUMN email").Error
+	}
+	if done, err := m.Rollback(ctx, 1); err != nil || len(done) != 2 || done[0].Version != 3 {
+		t.Fatalf("unexpected rollback: %v %v", done, err)
+	}
+	if db.Migrator().HasTable("roles") || !db.Migrator().HasTable("users") {
+		t.Fatal("unexpected tables after rollback")
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Applied || status[1].Applied || status[2].Applied {
+		t.Fatalf("unexpected status: %+v %+v %+v", status[0], status[1], status[2])
+	}
+}
+
+func TestMigrateConcurrent(t *testing.T) {
+	db := open(t)
+
+	var (
+		swg   sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for i := 0; i < 4; i++ {
+		swg.Add(1)
+		go func() {
+			defer swg.Done()
+
+			done, err := newMigrator(t, db, LockTimeout(10*time.Second)).Up(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			total += len(done)
+			mu.Unlock()
+		}()
+	}
+	swg.Wait()
+
+	if total != 3 {
+		t.Fatalf("expected 3 migrations applied once, got %d", total)
+	}
+}
+
+func TestMigrateLockRenew(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = open(t)
+		m   = New(db, LockExpire(30*time.Millisecond), LockTimeout(100*time.Millisecond))
+	)
+
+	unlock, err := m.tableLock(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 获取锁的超时时间大于过期时间，锁已续期，其他实例无法获取
+	if _, err := m.tableLock(ctx, db); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout, got %v", err)
+	}
+
+	unlock()
+	if unlock, err := m.tableLock(ctx, db); err != nil {
+		t.Fatal(err)
+	} else {
+		unlock()
+	}
+}
+
+func TestSplit(t *testing.T) {
+	sql := `
+-- comment;
+CREATE TABLE users (name TEXT DEFAULT ';'); /* comment; */
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at = now(); -- ;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+SELECT $body$a;b$body$, $1; -- trailing
+`
+	expected := []string{
+		"CREATE TABLE users (name TEXT DEFAULT ';')",
+		"CREATE FUNCTION touch() RETURNS trigger AS $$\nBEGIN\n\tNEW.updated_at = now(); -- ;\n\tRETURN NEW;\nEND;\n$$ LANGUAGE plpgsql",
+		"SELECT $body$a;b$body$, $1",
+	}
+
+	stmts := split(sql)
+	if len(stmts) != len(expected) {
+		t.Fatalf("expected %d statements, got %d: %q", len(expected), len(stmts), stmts)
+	}
+	for i, stmt := range stmts {
+		if stmt != expected[i] {
+			t.Fatalf("statement %d: expected %q, got %q", i, expected[i], stmt)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package orm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/charlesbases/hfw/metadata"
+	"github.com/charlesbases/hfw/xtime"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// auditName gorm plugin name
+	auditName = "hfw:audit"
+	// auditBefore 保存变更前记录的 key
+	auditBefore = "hfw:audit:before"
+	// auditInternal 标记审计插件自身执行的语句，其他插件不处理该语句
+	auditInternal = "hfw:audit:internal"
+)
+
+const (
+	// columnCreatedBy 创建人
+	columnCreatedBy = "created_by"
+	// columnUpdatedBy 更新人
+	columnUpdatedBy = "updated_by"
+	// columnCreatedAt 创建时间
+	columnCreatedAt = "created_at"
+	// columnUpdatedAt 更新时间
+	columnUpdatedAt = "updated_at"
+	// columnDeletedBy 删除人
+	columnDeletedBy = "deleted_by"
+	// columnDeletedAt 软删除时间
+	columnDeletedAt = "deleted_at"
+)
+
+const (
+	// ActionCreate .
+	ActionCreate = "create"
+	// ActionUpdate .
+	ActionUpdate = "update"
+	// ActionDelete .
+	ActionDelete = "delete"
+)
+
+// ChangeLogged 实现该接口的模型在增删改时写入 ChangeLog，需先 AutoMigrate(new(orm.ChangeLog))
+type ChangeLogged interface {
+	ChangeLogged()
+}
+
+// ChangeLog 变更记录
+type ChangeLog struct {
+	ID int64 `gorm:"primaryKey" json:"id"`
+	// Table 表名
+	Table string `gorm:"column:table_name;size:64;index" json:"table"`
+	// RecordID 主键
+	RecordID string `gorm:"size:64;index" json:"record_id"`
+	// Action ActionCreate | ActionUpdate | ActionDelete
+	Action string `gorm:"size:16" json:"action"`
+	// Before 变更前的记录(json)
+	Before string `json:"before"`
+	// After 变更后的记录(json)
+	After string `json:"after"`
+	// Operator 操作人
+	Operator string `gorm:"size:64" json:"operator"`
+	// CreatedAt 毫秒时间戳
+	CreatedAt int64 `json:"created_at"`
+}
+
+// TableName .
+func (*ChangeLog) TableName() string {
+	return "change_logs"
+}
+
+// audit 审计字段及变更记录
+//   - created_by、updated_by: 使用 context 中 metadata.Metadata 的 key 对应的值
+//   - created_at、updated_at: gorm 未管理的字段(如 string)使用 xtime 填充
+//   - deleted_by: 模型使用 gorm.DeletedAt 软删除时，删除前填充删除人
+//   - ChangeLogged: 写入 ChangeLog
+type audit struct {
+	// key 操作人在 metadata 中的 key
+	key string
+}
+
+// Name .
+func (a *audit) Name() string {
+	return auditName
+}
+
+// Initialize .
+func (a *audit) Initialize(db *gorm.DB) error {
+	var errs = []error{
+		db.Callback().Create().Before("gorm:create").Register(auditName+":before", a.beforeCreate),
+		db.Callback().Create().After("gorm:create").Register(auditName+":after", a.afterCreate),
+		db.Callback().Update().Before("gorm:update").Register(auditName+":before", a.beforeUpdate),
+		db.Callback().Update().After("gorm:update").Register(auditName+":after", a.afterUpdate),
+		db.Callback().Delete().Before("gorm:delete").Register(auditName+":before", a.beforeDelete),
+		db.Callback().Delete().After("gorm:delete").Register(auditName+":after", a.afterDelete),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// operator .
+func (a *audit) operator(db *gorm.DB) interface{} {
+	if db.Statement.Context == nil {
+		return nil
+	}
+	return metadata.Value(db.Statement.Context, a.key)
+}
+
+// now 按字段类型返回当前时间
+func now(field *schema.Field) interface{} {
+	switch field.FieldType.Kind() {
+	case reflect.String:
+		return xtime.Now()
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return xtime.NowTimestamp()
+	default:
+		return time.Now()
+	}
+}
+
+// beforeCreate .
+func (a *audit) beforeCreate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+
+	var values = make(map[*schema.Field]interface{})
+	if operator := a.operator(db); operator != nil {
+		for _, name := range []string{columnCreatedBy, columnUpdatedBy} {
+			if field := stmt.Schema.LookUpField(name); field != nil {
+				values[field] = operator
+			}
+		}
+	}
+	if field := stmt.Schema.LookUpField(columnCreatedAt); field != nil && field.AutoCreateTime == 0 {
+		values[field] = now(field)
+	}
+	if field := stmt.Schema.LookUpField(columnUpdatedAt); field != nil && field.AutoUpdateTime == 0 {
+		values[field] = now(field)
+	}
+
+	// 仅填充零值字段
+	set := func(rv reflect.Value) {
+		for field, val := range values {
+			if _, zero := field.ValueOf(stmt.Context, rv); zero {
+				db.AddError(field.Set(stmt.Context, rv, val))
+			}
+		}
+	}
+
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
+
+// afterCreate .
+func (a *audit) afterCreate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || !changeLogged(stmt) {
+		return
+	}
+
+	var logs = make([]*ChangeLog, 0)
+	add := func(rv reflect.Value) {
+		after, err := json.Marshal(rv.Interface())
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		id, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, rv)
+		logs = append(logs, a.changeLog(db, ActionCreate, id, "", string(after)))
+	}
+
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			add(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		add(rv)
+	}
+	a.write(db, logs)
+}
+
+// beforeUpdate .
+func (a *audit) beforeUpdate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || internal(db) {
+		return
+	}
+
+	if operator := a.operator(db); operator != nil && stmt.Schema.LookUpField(columnUpdatedBy) != nil {
+		stmt.SetColumn(columnUpdatedBy, operator, true)
+	}
+	if field := stmt.Schema.LookUpField(columnUpdatedAt); field != nil && field.AutoUpdateTime == 0 {
+		stmt.SetColumn(columnUpdatedAt, now(field), true)
+	}
+
+	if changeLogged(stmt) {
+		db.InstanceSet(auditBefore, a.snapshot(db))
+	}
+}
+
+// afterUpdate .
+func (a *audit) afterUpdate(db *gorm.DB) {
+	a.after(db, ActionUpdate)
+}
+
+// beforeDelete .
+func (a *audit) beforeDelete(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	if changeLogged(db.Statement) {
+		db.InstanceSet(auditBefore, a.snapshot(db))
+	}
+	a.softDelete(db)
+}
+
+// softDelete 软删除时填充 deleted_by。
+// gorm 的软删除会覆盖 SET 子句，因此在同一连接(事务)中按删除条件单独更新
+func (a *audit) softDelete(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Unscoped {
+		return
+	}
+	field, deletedAt := stmt.Schema.LookUpField(columnDeletedBy), stmt.Schema.LookUpField(columnDeletedAt)
+	if field == nil || deletedAt == nil || deletedAt.FieldType != reflect.TypeOf(gorm.DeletedAt{}) {
+		return
+	}
+	operator := a.operator(db)
+	if operator == nil {
+		return
+	}
+
+	query, where := a.where(db, a.session(db).Set(auditInternal, true))
+	if !where {
+		return
+	}
+	db.AddError(query.Where(clause.Eq{Column: clause.Column{Name: deletedAt.DBName}, Value: nil}).
+		UpdateColumns(map[string]interface{}{field.DBName: operator}).Error)
+}
+
+// afterDelete .
+func (a *audit) afterDelete(db *gorm.DB) {
+	a.after(db, ActionDelete)
+}
+
+// after 根据变更前记录的主键查询变更后的记录
+func (a *audit) after(db *gorm.DB, action string) {
+	if db.Error != nil {
+		return
+	}
+	val, ok := db.InstanceGet(auditBefore)
+	if !ok {
+		return
+	}
+	before := val.([]map[string]interface{})
+	if len(before) == 0 {
+		return
+	}
+
+	var (
+		stmt = db.Statement
+		pk   = stmt.Schema.PrioritizedPrimaryField
+		ids  = make([]interface{}, 0, len(before))
+	)
+	for _, row := range before {
+		ids = append(ids, row[pk.DBName])
+	}
+
+	var after = make(map[string]map[string]interface{}, len(before))
+	if action != ActionDelete {
+		var rows = make([]map[string]interface{}, 0, len(before))
+		if err := a.session(db).Where(clause.IN{Column: clause.Column{Name: pk.DBName}, Values: ids}).Find(&rows).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+		for _, row := range rows {
+			after[fmt.Sprint(row[pk.DBName])] = row
+		}
+	}
+
+	var logs = make([]*ChangeLog, 0, len(before))
+	for _, row := range before {
+		id := row[pk.DBName]
+
+		b, err := json.Marshal(row)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		var aj []byte
+		if row, found := after[fmt.Sprint(id)]; found {
+			if aj, err = json.Marshal(row); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+		logs = append(logs, a.changeLog(db, action, id, string(b), string(aj)))
+	}
+	a.write(db, logs)
+}
+
+// session 使用当前连接(事务)查询当前表
+func (a *audit) session(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Model(reflect.New(db.Statement.Schema.ModelType).Interface()).Table(db.Statement.Table)
+}
+
+// snapshot 查询将被修改的记录。无查询条件时不查询(gorm 会返回 ErrMissingWhereClause)
+func (a *audit) snapshot(db *gorm.DB) []map[string]interface{} {
+	query, where := a.where(db, a.session(db))
+	if !where {
+		return nil
+	}
+
+	var rows = make([]map[string]interface{}, 0)
+	if err := query.Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return nil
+	}
+	return rows
+}
+
+// where 将当前语句的查询条件及主键添加到 query，返回是否存在条件
+func (a *audit) where(db *gorm.DB, query *gorm.DB) (*gorm.DB, bool) {
+	var (
+		stmt  = db.Statement
+		where bool
+	)
+
+	if c, found := stmt.Clauses["WHERE"]; found {
+		if expr, ok := c.Expression.(clause.Where); ok && len(expr.Exprs) != 0 {
+			query, where = query.Clauses(expr), true
+		}
+	}
+	// db.Model(&user).Updates(...)、db.Delete(&user)
+	if pk := stmt.Schema.PrioritizedPrimaryField; pk != nil {
+		if rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() == reflect.Struct {
+			if id, zero := pk.ValueOf(stmt.Context, rv); !zero {
+				query, where = query.Where(clause.Eq{Column: clause.Column{Name: pk.DBName}, Value: id}), true
+			}
+		}
+	}
+	return query, where
+}
+
+// changeLog .
+func (a *audit) changeLog(db *gorm.DB, action string, id interface{}, before, after string) *ChangeLog {
+	var operator string
+	if val := a.operator(db); val != nil {
+		operator = fmt.Sprint(val)
+	}
+
+	return &ChangeLog{
+		Table:     db.Statement.Table,
+		RecordID:  fmt.Sprint(id),
+		Action:    action,
+		Before:    before,
+		After:     after,
+		Operator:  operator,
+		CreatedAt: xtime.NowTimestamp(),
+	}
+}
+
+// write 在当前连接(事务)中写入变更记录
+func (a *audit) write(db *gorm.DB, logs []*ChangeLog) {
+	if len(logs) != 0 {
+		db.AddError(db.Session(&gorm.Session{NewDB: true}).Create(&logs).Error)
+	}
+}
+
+// internal 是否为审计插件自身执行的语句
+func internal(db *gorm.DB) bool {
+	_, ok := db.Get(auditInternal)
+	return ok
+}
+
+// changeLogged .
+func changeLogged(stmt *gorm.Statement) bool {
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	_, ok := reflect.New(stmt.Schema.ModelType).Interface().(ChangeLogged)
+	return ok
+}
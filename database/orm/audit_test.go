@@ -0,0 +1,130 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/hfw/metadata"
+	"github.com/charlesbases/hfw/xtime"
+	"gorm.io/gorm"
+)
+
+type Article struct {
+	ID        int64
+	Title     string
+	CreatedBy string
+	UpdatedBy string
+	CreatedAt string
+	UpdatedAt time.Time
+}
+
+func (*Article) ChangeLogged() {}
+
+func TestAudit(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.AutoMigrate(new(Article), new(ChangeLog)); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		alice = metadata.Metadata{"user": "alice"}.WithContext(context.Background())
+		bob   = metadata.Metadata{"user": "bob"}.WithContext(context.Background())
+		repo  = NewRepository[Article](db)
+	)
+
+	var article = &Article{Title: "draft"}
+	if err := repo.Create(alice, article); err != nil {
+		t.Fatal(err)
+	}
+	if article.CreatedBy != "alice" || article.UpdatedBy != "alice" || xtime.Parse(article.CreatedAt).IsZero() {
+		t.Fatalf("unexpected article: %+v", article)
+	}
+
+	if err := repo.Update(bob, article.ID, map[string]interface{}{"title": "published"}); err != nil {
+		t.Fatal(err)
+	}
+	if article, _ = repo.Get(bob, article.ID); article.CreatedBy != "alice" || article.UpdatedBy != "bob" {
+		t.Fatalf("unexpected article: %+v", article)
+	}
+
+	if err := repo.Delete(bob, article.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs []*ChangeLog
+	if err := db.Order("id").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 change logs, got %d", len(logs))
+	}
+
+	for i, expected := range []struct {
+		action, operator, before, after string
+	}{
+		{ActionCreate, "alice", "", "draft"},
+		{ActionUpdate, "bob", "draft", "published"},
+		{ActionDelete, "bob", "published", ""},
+	} {
+		log := logs[i]
+		if log.Table != "articles" || log.RecordID != "1" || log.Action != expected.action || log.Operator != expected.operator {
+			t.Fatalf("unexpected change log: %+v", log)
+		}
+		if !strings.Contains(log.Before, expected.before) || !strings.Contains(log.After, expected.after) ||
+			(len(expected.before) == 0) != (len(log.Before) == 0) || (len(expected.after) == 0) != (len(log.After) == 0) {
+			t.Fatalf("unexpected change log: %+v", log)
+		}
+	}
+}
+
+type Comment struct {
+	ID        int64
+	Content   string
+	DeletedBy string
+	DeletedAt gorm.DeletedAt
+}
+
+func TestAuditSoftDelete(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.AutoMigrate(new(Comment)); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		alice = metadata.Metadata{"user": "alice"}.WithContext(context.Background())
+		bob   = metadata.Metadata{"user": "bob"}.WithContext(context.Background())
+		repo  = NewRepository[Comment](db)
+	)
+
+	var comment = &Comment{Content: "hello"}
+	if err := repo.Create(alice, comment); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(bob, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Get(bob, comment.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	var deleted Comment
+	if err := db.Unscoped().First(&deleted, comment.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if deleted.DeletedBy != "bob" || !deleted.DeletedAt.Valid {
+		t.Fatalf("unexpected comment: %+v", deleted)
+	}
+
+	// 已删除的记录不会被再次修改删除人
+	if err := db.WithContext(alice).Delete(new(Comment), comment.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Unscoped().First(&deleted, comment.ID).Error; err != nil || deleted.DeletedBy != "bob" {
+		t.Fatalf("unexpected comment: %+v %v", deleted, err)
+	}
+}
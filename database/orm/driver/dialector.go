@@ -10,11 +10,14 @@ const (
 	TypeMysql Type = "Mysql"
 	// TypePostgres postgres
 	TypePostgres Type = "Postgres"
+	// TypeSqlite sqlite
+	TypeSqlite Type = "Sqlite"
 )
 
 type Type string
 
 type Dialector interface {
-	Dialector(optons *database.Options) gorm.Dialector
+	// Dialector dsn 无效时返回 *database.DsnError
+	Dialector(optons *database.Options) (gorm.Dialector, error)
 	Type() Type
 }
@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charlesbases/hfw/database"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// mysql error number
+const (
+	mysqlDuplicateEntry   = 1062
+	mysqlRowIsReferenced  = 1451
+	mysqlNoReferencedRow  = 1452
+	mysqlLockWaitTimeout  = 1205
+	mysqlCheckViolated    = 3819
+	mysqlMaxExecutionTime = 3024
+	mysqlServerShutdown   = 1053
+	mysqlConnectionKilled = 1927
+	mysqlServerGone       = 2006
+	mysqlServerLost       = 2013
+	// mysqlDeadlock ER_LOCK_DEADLOCK
+	mysqlDeadlock = 1213
+)
+
+// postgres sqlstate
+const (
+	postgresUniqueViolation = "23505"
+	postgresForeignKey      = "23503"
+	postgresCheckViolation  = "23514"
+	postgresQueryCanceled   = "57014"
+	postgresLockNotAvail    = "55P03"
+	postgresAdminShutdown   = "57P01"
+	// postgresConnection 08xxx connection exception
+	postgresConnection = "08"
+	// postgresSerializationFailure serialization_failure
+	postgresSerializationFailure = "40001"
+	// postgresDeadlock deadlock_detected
+	postgresDeadlock = "40P01"
+)
+
+// sqlite (extended) result code
+const (
+	// sqliteBusy SQLITE_BUSY
+	sqliteBusy                 = 5
+	sqliteConstraintCheck      = 275
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// Retryable 死锁或序列化失败等重新执行事务即可能成功的错误
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == postgresSerializationFailure || pgErr.Code == postgresDeadlock
+	}
+
+	// sqlite 错误码的低 8 位为主错误码
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xff == sqliteBusy
+	}
+	return false
+}
+
+func init() {
+	database.RegisterClassifier(classifier{})
+}
+
+// classifier 识别 gorm 及 mysql、postgres、sqlite 驱动的错误
+type classifier struct{}
+
+// Retryable .
+func (classifier) Retryable(err error) bool {
+	return Retryable(err)
+}
+
+// Classify .
+func (classifier) Classify(err error) (error, string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return database.ErrorNotFound, ""
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return database.ErrorUniqueViolation, ""
+	case errors.Is(err, mysqldriver.ErrInvalidConn):
+		return database.ErrorConnectionLost, ""
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return database.ErrorUniqueViolation, ""
+		case mysqlRowIsReferenced, mysqlNoReferencedRow:
+			return database.ErrorForeignKeyViolation, ""
+		case mysqlCheckViolated:
+			return database.ErrorCheckViolation, ""
+		case mysqlLockWaitTimeout, mysqlMaxExecutionTime:
+			return database.ErrorTimeout, ""
+		case mysqlServerShutdown, mysqlConnectionKilled, mysqlServerGone, mysqlServerLost:
+			return database.ErrorConnectionLost, ""
+		}
+		return nil, ""
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == postgresUniqueViolation:
+			return database.ErrorUniqueViolation, pgErr.ConstraintName
+		case pgErr.Code == postgresForeignKey:
+			return database.ErrorForeignKeyViolation, pgErr.ConstraintName
+		case pgErr.Code == postgresCheckViolation:
+			return database.ErrorCheckViolation, pgErr.ConstraintName
+		case pgErr.Code == postgresQueryCanceled, pgErr.Code == postgresLockNotAvail:
+			return database.ErrorTimeout, ""
+		case pgErr.Code == postgresAdminShutdown, strings.HasPrefix(pgErr.Code, postgresConnection):
+			return database.ErrorConnectionLost, ""
+		}
+		return nil, ""
+	}
+
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqliteConstraintUnique, sqliteConstraintPrimaryKey:
+			return database.ErrorUniqueViolation, ""
+		case sqliteConstraintForeignKey:
+			return database.ErrorForeignKeyViolation, ""
+		case sqliteConstraintCheck:
+			return database.ErrorCheckViolation, ""
+		}
+	}
+	return nil, ""
+}
@@ -2,7 +2,7 @@ package driver
 
 import (
 	"github.com/charlesbases/hfw/database"
-	"github.com/charlesbases/logger"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -13,11 +13,14 @@ var MySQL *m
 type m struct{}
 
 // Dialector .
-func (m *m) Dialector(opts *database.Options) gorm.Dialector {
+func (m *m) Dialector(opts *database.Options) (gorm.Dialector, error) {
 	if len(opts.Address) == 0 {
-		logger.Fatal(database.ErrorInvaildDsn)
+		return nil, &database.DsnError{Driver: string(m.Type())}
 	}
-	return mysql.Open(opts.Address)
+	if _, err := mysqldriver.ParseDSN(opts.Address); err != nil {
+		return nil, &database.DsnError{Driver: string(m.Type()), Err: err}
+	}
+	return mysql.Open(opts.Address), nil
 }
 
 // Type .
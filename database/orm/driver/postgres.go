@@ -2,7 +2,7 @@ package driver
 
 import (
 	"github.com/charlesbases/hfw/database"
-	"github.com/charlesbases/logger"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -13,11 +13,14 @@ var Postgres *p
 type p struct{}
 
 // Dialector .
-func (p *p) Dialector(opts *database.Options) gorm.Dialector {
+func (p *p) Dialector(opts *database.Options) (gorm.Dialector, error) {
 	if len(opts.Address) == 0 {
-		logger.Fatal(database.ErrorInvaildDsn)
+		return nil, &database.DsnError{Driver: string(p.Type())}
 	}
-	return postgres.Open(opts.Address)
+	if _, err := pgconn.ParseConfig(opts.Address); err != nil {
+		return nil, &database.DsnError{Driver: string(p.Type()), Err: err}
+	}
+	return postgres.Open(opts.Address), nil
 }
 
 // Type .
@@ -0,0 +1,36 @@
+package driver
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// memories 内存数据库序号
+var memories uint64
+
+// SQLiteMemory 新的内存数据库地址。同一地址的连接共享数据，不同地址的数据库相互隔离
+func SQLiteMemory() string {
+	return fmt.Sprintf("file:memory-%d?mode=memory&cache=shared", atomic.AddUint64(&memories, 1))
+}
+
+// SQLite SQLite(pure go)，支持文件与内存数据库。Address 为空时每次打开新的内存数据库(SQLiteMemory)
+var SQLite *s
+
+type s struct{}
+
+// Dialector .
+func (s *s) Dialector(opts *database.Options) (gorm.Dialector, error) {
+	if len(opts.Address) == 0 {
+		return sqlite.Open(SQLiteMemory()), nil
+	}
+	return sqlite.Open(opts.Address), nil
+}
+
+// Type .
+func (s *s) Type() Type {
+	return TypeSqlite
+}
@@ -2,87 +2,191 @@ package orm
 
 import (
 	"context"
+	"database/sql"
 	"errors"
-	"fmt"
-	"sync"
 	"time"
 
 	"github.com/charlesbases/hfw/database"
 	"github.com/charlesbases/hfw/database/orm/driver"
 	"github.com/charlesbases/logger"
 	"gorm.io/gorm"
-	glogger "gorm.io/gorm/logger"
 )
 
-var (
-	once      sync.Once
-	defaultDB *gorm.DB
-)
-
-// New new db
-func New(fn driver.Dialector, opts ...database.Option) *gorm.DB {
-	var options = new(database.Options)
+// Open open db. 连接失败时根据 database.Retry 重试
+// dsn 无效时返回 *database.DsnError，连接失败时返回 *database.ConnectError
+func Open(ctx context.Context, fn driver.Dialector, opts ...database.Option) (*gorm.DB, error) {
+	var options = database.DefaultOptions()
 	for _, opt := range opts {
 		opt(options)
 	}
 
-	gormDB, err := gorm.Open(fn.Dialector(options), &gorm.Config{Logger: custom(fn.Type(), options.ShowSQL)})
+	for attempt := 1; ; attempt++ {
+		gormDB, err := connect(ctx, fn, options)
+		if err == nil {
+			return gormDB, nil
+		}
+
+		var dsnErr *database.DsnError
+		if errors.As(err, &dsnErr) {
+			return nil, err
+		}
+		if attempt > options.Retry {
+			return nil, &database.ConnectError{Driver: string(fn.Type()), Attempts: attempt, Err: err}
+		}
+
+		backoff := options.Backoff(attempt)
+		logger.Warnf("[%s] >>> connect failed, retry after %v. %v", fn.Type(), backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, &database.ConnectError{Driver: string(fn.Type()), Attempts: attempt, Err: ctx.Err()}
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// connect .
+func connect(ctx context.Context, fn driver.Dialector, options *database.Options) (*gorm.DB, error) {
+	dialector, err := fn.Dialector(options)
 	if err != nil {
-		logger.Fatalf("database connect failed. %v", err)
+		return nil, err
 	}
 
-	db, err := gormDB.DB()
+	gormDB, err := gorm.Open(dialector, &gorm.Config{
+		Logger:      custom(fn.Type(), options),
+		PrepareStmt: options.PrepareStmt,
+		// 使用 ConnectTimeout ping
+		DisableAutomaticPing: true,
+	})
 	if err != nil {
-		logger.Fatalf("database connect failed. %v", err)
+		closeDB(gormDB)
+		return nil, err
 	}
 
-	db.SetMaxIdleConns(options.MaxIdleConns)
-	db.SetMaxOpenConns(options.MaxOpenConns)
+	db, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	configure(db, options)
 
-	return gormDB
-}
+	if err := ping(ctx, db, options.ConnectTimeout); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-// Init init defaultDB
-func Init(fn driver.Dialector, opts ...database.Option) {
-	once.Do(func() {
-		var options = database.DefaultOptions()
-		for _, opt := range opts {
-			opt(options)
+	// 多租户
+	if len(options.TenantKey) != 0 {
+		if err := gormDB.Use(&tenant{key: options.TenantKey, schema: options.TenantSchema}); err != nil {
+			db.Close()
+			return nil, err
 		}
+	}
 
-		gormDB, err := gorm.Open(fn.Dialector(options), &gorm.Config{Logger: custom(fn.Type(), options.ShowSQL)})
-		if err != nil {
-			logger.Fatalf("database connect failed. %v", err)
-		}
+	// 审计字段及变更记录
+	if err := gormDB.Use(&audit{key: options.AuditKey}); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-		defaultDB = gormDB
+	// 乐观锁
+	if err := gormDB.Use(new(version)); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-		db, err := gormDB.DB()
+	// sql 执行超时
+	if options.QueryTimeout > 0 {
+		if err := gormDB.Use(&timeout{timeout: options.QueryTimeout}); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	// 读写分离
+	if len(options.Replicas) != 0 {
+		r, err := newResolver(ctx, fn, options)
 		if err != nil {
-			logger.Fatalf("database connect failed. %v", err)
+			db.Close()
+			return nil, err
+		}
+		if err := gormDB.Use(r); err != nil {
+			r.close()
+			db.Close()
+			return nil, err
 		}
+	}
+	return gormDB, nil
+}
 
+// configure 连接池配置。未配置时使用 database/sql 默认值
+func configure(db *sql.DB, options *database.Options) {
+	// MaxIdleConns 为 0 时，内存数据库会随连接关闭而丢失
+	if options.MaxIdleConns > 0 {
 		db.SetMaxIdleConns(options.MaxIdleConns)
+	}
+	if options.MaxOpenConns > 0 {
 		db.SetMaxOpenConns(options.MaxOpenConns)
-	})
+	}
+	if options.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(options.ConnMaxLifetime)
+	}
+	if options.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(options.ConnMaxIdleTime)
+	}
+}
+
+// ping .
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return db.PingContext(ctx)
+}
+
+// closeDB .
+func closeDB(gormDB *gorm.DB) {
+	if gormDB == nil || gormDB.Config == nil || gormDB.ConnPool == nil {
+		return
+	}
+	if db, err := gormDB.DB(); err == nil {
+		db.Close()
+	}
 }
 
-// DB return defaultDB
-func DB() *gorm.DB {
-	if defaultDB != nil {
-		return defaultDB
-	} else {
-		logger.Fatal(database.ErrorDatabaseNil)
-		return nil
+// New new db. 连接失败时 logger.Fatal
+//
+// Deprecated: use Open
+func New(fn driver.Dialector, opts ...database.Option) *gorm.DB {
+	gormDB, err := Open(context.Background(), fn, opts...)
+	if err != nil {
+		logger.Fatalf("database connect failed. %v", err)
 	}
+	return gormDB
+}
+
+// Init 注册名为 DefaultName 的数据库。已注册时返回 database.ErrorDatabaseExists
+func Init(fn driver.Dialector, opts ...database.Option) error {
+	return Register(DefaultName, fn, opts...)
+}
+
+// DB 返回名为 DefaultName 的数据库
+func DB() (*gorm.DB, error) {
+	return Get(DefaultName)
 }
 
-// Transaction .
+// Transaction 依次执行 fs，任一返回 error 或 panic 时回滚(panic 会在回滚后重新抛出)
+// 需要嵌套事务时使用 Tx
 func Transaction(gormDB *gorm.DB, fs ...func(tx *gorm.DB) error) error {
 	tx := gormDB.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
+			panic(r)
 		}
 	}()
 
@@ -99,44 +203,3 @@ func Transaction(gormDB *gorm.DB, fs ...func(tx *gorm.DB) error) error {
 	}
 	return nil
 }
-
-// l .
-type l struct {
-	debug  bool
-	driver string
-}
-
-// custom .
-func custom(dt driver.Type, debug bool) glogger.Interface {
-	return &l{driver: fmt.Sprintf("[%s] >>> ", dt), debug: debug}
-}
-
-func (l *l) LogMode(level glogger.LogLevel) glogger.Interface {
-	return l
-}
-
-func (l *l) Info(ctx context.Context, format string, v ...interface{}) {
-	logger.Infof(l.driver+format, v...)
-}
-
-func (l *l) Warn(ctx context.Context, format string, v ...interface{}) {
-	logger.Warnf(l.driver+format, v...)
-}
-
-func (l *l) Error(ctx context.Context, format string, v ...interface{}) {
-	logger.Errorf(l.driver+format, v...)
-}
-
-func (l *l) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
-	if l.debug {
-		elapsed := time.Since(begin)
-		sql, rows := fc()
-
-		switch {
-		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
-			logger.Errorf(l.driver+"%s | %v", sql, err)
-		default:
-			logger.Debugf(l.driver+"%s | %d rows | %v", sql, rows, elapsed)
-		}
-	}
-}
@@ -0,0 +1,115 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"gorm.io/gorm"
+)
+
+// openTestDB 在 t.TempDir() 中打开 SQLite 数据库, 测试结束时自动关闭
+func openTestDB(t testing.TB, opts ...database.Option) *gorm.DB {
+	t.Helper()
+
+	db, err := Open(context.Background(), driver.SQLite,
+		append([]database.Option{database.Address("file:" + t.TempDir() + "/test.db")}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close(db) })
+	return db
+}
+
+type User struct {
+	ID   int64
+	Name string
+}
+
+func TestSQLite(t *testing.T) {
+	db := New(driver.SQLite, database.Address("file:"+t.TempDir()+"/test.db"))
+
+	if err := db.AutoMigrate(new(User)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Transaction(db,
+		func(tx *gorm.DB) error {
+			return tx.Create(&User{Name: "a"}).Error
+		},
+		func(tx *gorm.DB) error {
+			return errors.New("rollback")
+		},
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var count int64
+	if err := db.Model(new(User)).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("expected rollback, got %d users", count)
+	}
+}
+
+func TestSQLiteMemory(t *testing.T) {
+	db := New(driver.SQLite)
+
+	if err := db.AutoMigrate(new(User)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&User{Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var user = new(User)
+	if err := db.First(user, "name = ?", "a").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认的内存数据库相互隔离
+	other := New(driver.SQLite)
+	if other.Migrator().HasTable(new(User)) {
+		t.Fatal("expected isolated memory database")
+	}
+}
+
+func TestOpenInvalidDsn(t *testing.T) {
+	for _, fn := range []driver.Dialector{driver.MySQL, driver.Postgres} {
+		for _, dsn := range []string{"", "://invalid"} {
+			_, err := Open(context.Background(), fn, database.Address(dsn))
+
+			var dsnErr *database.DsnError
+			if !errors.As(err, &dsnErr) || !errors.Is(err, database.ErrorInvaildDsn) {
+				t.Fatalf("%s %q: expected DsnError, got %v", fn.Type(), dsn, err)
+			}
+		}
+	}
+
+	// Unwrap 返回解析错误
+	_, err := Open(context.Background(), driver.Postgres, database.Address("://invalid"))
+	var dsnErr *database.DsnError
+	if !errors.As(err, &dsnErr) || dsnErr.Err == nil || errors.Unwrap(dsnErr) != dsnErr.Err {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestOpenRetry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := Open(ctx, driver.MySQL, database.Address("root:root@tcp(127.0.0.1:1)/test?timeout=10ms"), database.Retry(10, 10*time.Millisecond))
+
+	var connErr *database.ConnectError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected ConnectError, got %v", err)
+	}
+	if connErr.Attempts < 2 {
+		t.Fatalf("expected retry, got %d attempts", connErr.Attempts)
+	}
+}
@@ -0,0 +1,85 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultHealthTimeout ctx 未设置 deadline 时的 ping 超时时间
+const defaultHealthTimeout = 3 * time.Second
+
+// Status 数据库健康状态，用于 readiness 检查
+type Status struct {
+	// Name 注册名称
+	Name string `json:"name"`
+	// Healthy 主库 ping 成功
+	Healthy bool `json:"healthy"`
+	// Latency ping 延迟
+	Latency time.Duration `json:"latency"`
+	// Error ping 失败的原因
+	Error string `json:"error,omitempty"`
+	// Replicas 从库数量
+	Replicas int `json:"replicas,omitempty"`
+	// HealthyReplicas 健康的从库数量
+	HealthyReplicas int `json:"healthy_replicas,omitempty"`
+	// Stats 连接池状态
+	Stats sql.DBStats `json:"stats"`
+}
+
+// Check ping 数据库并返回连接池状态
+func Check(ctx context.Context, name string, gormDB *gorm.DB) *Status {
+	var status = &Status{Name: name}
+
+	db, err := gormDB.DB()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	var timeout time.Duration
+	if _, ok := ctx.Deadline(); !ok {
+		timeout = defaultHealthTimeout
+	}
+
+	start := time.Now()
+	if err := ping(ctx, db, timeout); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Healthy = true
+	}
+	status.Latency = time.Since(start)
+	status.Stats = db.Stats()
+
+	if r, ok := gormDB.Config.Plugins[resolverName].(*resolver); ok {
+		status.Replicas = len(r.replicas)
+		for _, rep := range r.replicas {
+			if rep.healthy.Load() {
+				status.HealthyReplicas++
+			}
+		}
+	}
+	return status
+}
+
+// Health 检查所有已注册的数据库，按名称排序
+func Health(ctx context.Context) []*Status {
+	var list = make([]*Status, 0)
+	Range(func(name string, gormDB *gorm.DB) bool {
+		list = append(list, Check(ctx, name, gormDB))
+		return true
+	})
+	return list
+}
+
+// Healthy 所有已注册的数据库均健康
+func Healthy(ctx context.Context) bool {
+	for _, status := range Health(ctx) {
+		if !status.Healthy {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/hfw/lifecycle"
+)
+
+func TestHealth(t *testing.T) {
+	lf := new(lifecycle.Lifecycle)
+	lf.Append(Lifecycle("health", driver.SQLite, database.Address("file:"+t.TempDir()+"/test.db")))
+
+	if err := lf.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var status *Status
+	for _, item := range Health(context.Background()) {
+		if item.Name == "health" {
+			status = item
+		}
+	}
+	if status == nil || !status.Healthy || status.Latency <= 0 || status.Stats.OpenConnections == 0 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	if err := lf.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get("health"); !errors.Is(err, database.ErrorDatabaseNil) {
+		t.Fatalf("expected ErrorDatabaseNil, got %v", err)
+	}
+}
+
+func TestLifecycleRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	hook := Lifecycle("unreachable", driver.MySQL, database.Address("root:root@tcp(127.0.0.1:1)/test"), database.Retry(10, 0))
+	var connErr *database.ConnectError
+	if err := hook.OnStart(ctx); !errors.As(err, &connErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled ConnectError, got %v", err)
+	}
+}
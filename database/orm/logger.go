@@ -0,0 +1,168 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/hfw/metadata"
+	"github.com/charlesbases/logger"
+	"gorm.io/gorm"
+	glogger "gorm.io/gorm/logger"
+)
+
+// l gorm logger
+//   - Info: 输出所有 sql(按 LogSample 采样)
+//   - Warn: 输出慢查询及错误。ShowSQL 为 false 时的默认级别
+//   - Error: 仅输出错误
+//   - Silent: 不输出日志
+//
+// Observer 不受日志级别影响
+type l struct {
+	level  glogger.LogLevel
+	driver string
+
+	slow     time.Duration
+	sample   float64
+	redact   bool
+	metadata []string
+	observer func(ctx context.Context, stmt *database.Statement)
+}
+
+// custom .
+func custom(dt driver.Type, options *database.Options) glogger.Interface {
+	var level = glogger.Warn
+	if options.ShowSQL {
+		level = glogger.Info
+	}
+
+	var sample = options.LogSample
+	if sample <= 0 {
+		sample = 1
+	}
+
+	return &l{
+		level:    level,
+		driver:   string(dt),
+		slow:     options.SlowThreshold,
+		sample:   sample,
+		redact:   options.LogRedact,
+		metadata: options.LogMetadata,
+		observer: options.Observer,
+	}
+}
+
+// LogMode .
+func (l *l) LogMode(level glogger.LogLevel) glogger.Interface {
+	nl := *l
+	nl.level = level
+	return &nl
+}
+
+// Info .
+func (l *l) Info(ctx context.Context, format string, v ...interface{}) {
+	if l.level >= glogger.Info {
+		logger.Infof("%s", l.message(ctx, format, v...))
+	}
+}
+
+// Warn .
+func (l *l) Warn(ctx context.Context, format string, v ...interface{}) {
+	if l.level >= glogger.Warn {
+		logger.Warnf("%s", l.message(ctx, format, v...))
+	}
+}
+
+// Error .
+func (l *l) Error(ctx context.Context, format string, v ...interface{}) {
+	if l.level >= glogger.Error {
+		logger.Errorf("%s", l.message(ctx, format, v...))
+	}
+}
+
+// Trace .
+func (l *l) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= glogger.Silent && l.observer == nil {
+		return
+	}
+
+	var (
+		elapsed = time.Since(begin)
+		slow    = l.slow > 0 && elapsed > l.slow
+		failed  = err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	)
+
+	// fc 会拼接 sql，仅在需要时调用一次
+	var (
+		sql  string
+		rows int64
+		done bool
+	)
+	explain := func() {
+		if !done {
+			sql, rows = fc()
+			done = true
+		}
+	}
+
+	if l.observer != nil {
+		explain()
+		l.observer(ctx, &database.Statement{Driver: l.driver, SQL: sql, Rows: rows, Elapsed: elapsed, Slow: slow, Err: err})
+	}
+
+	switch {
+	case failed && l.level >= glogger.Error:
+		explain()
+		logger.Errorf("%s", l.message(ctx, "%s | %d rows | %v | %v", sql, rows, elapsed, err))
+	case slow && l.level >= glogger.Warn:
+		explain()
+		logger.Warnf("%s", l.message(ctx, "slow sql(>%v) %s | %d rows | %v", l.slow, sql, rows, elapsed))
+	case l.level >= glogger.Info && (l.sample >= 1 || rand.Float64() < l.sample):
+		explain()
+		logger.Debugf("%s", l.message(ctx, "%s | %d rows | %v", sql, rows, elapsed))
+	}
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter。LogRedact 时日志中的参数显示为 ?
+func (l *l) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.redact {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// message 格式化日志。metadata 作为参数拼接，其中的 % 不会被解析
+func (l *l) message(ctx context.Context, format string, v ...interface{}) string {
+	return l.prefix(ctx) + fmt.Sprintf(format, v...)
+}
+
+// prefix [driver] >>> request_id=xxx user=xxx |
+func (l *l) prefix(ctx context.Context) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("[%s] >>> ", l.driver))
+
+	if ctx == nil {
+		return b.String()
+	}
+	md, ok := metadata.FromContext(ctx)
+	if !ok || md.Len() == 0 {
+		return b.String()
+	}
+
+	var n int
+	for _, key := range l.metadata {
+		if val, found := md[key]; found {
+			b.WriteString(fmt.Sprintf("%s=%v ", key, val))
+			n++
+		}
+	}
+	if n != 0 {
+		b.WriteString("| ")
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/hfw/metadata"
+)
+
+func TestLogger(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		stmts []*database.Statement
+	)
+
+	db := openTestDB(t,
+		database.LogRedact(true),
+		database.Observer(func(ctx context.Context, stmt *database.Statement) {
+			mu.Lock()
+			stmts = append(stmts, stmt)
+			mu.Unlock()
+		}),
+	)
+
+	if err := db.AutoMigrate(new(User)); err != nil {
+		t.Fatal(err)
+	}
+
+	stmts = nil
+	if err := db.Create(&User{Name: "secret"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stmts) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(stmts))
+	}
+	if stmt := stmts[0]; strings.Contains(stmt.SQL, "secret") || stmt.Rows != 1 || stmt.Driver != string(driver.TypeSqlite) {
+		t.Fatalf("unexpected statement: %+v", stmt)
+	}
+}
+
+func TestLoggerPrefix(t *testing.T) {
+	ctx := metadata.Metadata{"request_id": "abc", "user": 1, "token": "secret"}.WithContext(context.Background())
+
+	// 默认不输出 metadata
+	none := custom(driver.TypeSqlite, database.DefaultOptions()).(*l)
+	if prefix := none.prefix(ctx); prefix != "[Sqlite] >>> " {
+		t.Fatalf("unexpected prefix: %q", prefix)
+	}
+
+	some := custom(driver.TypeSqlite, &database.Options{LogMetadata: []string{"user", "request_id"}}).(*l)
+	if prefix := some.prefix(ctx); prefix != "[Sqlite] >>> user=1 request_id=abc | " {
+		t.Fatalf("unexpected prefix: %q", prefix)
+	}
+	if prefix := some.prefix(context.Background()); prefix != "[Sqlite] >>> " {
+		t.Fatalf("unexpected prefix: %q", prefix)
+	}
+
+	// metadata 中的 % 不作为格式化动词
+	ctx = metadata.Metadata{"user": "100%d"}.WithContext(context.Background())
+	if msg := some.message(ctx, "%s | %d rows", "SELECT 1", 1); msg != "[Sqlite] >>> user=100%d | SELECT 1 | 1 rows" {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
@@ -0,0 +1,30 @@
+// Package ormtest 为测试提供基于临时目录的 SQLite 数据库
+package ormtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"gorm.io/gorm"
+)
+
+// Open 在 t.TempDir() 中打开 SQLite 数据库, 测试结束时自动关闭. pragmas 如: "foreign_keys(1)"
+func Open(t testing.TB, pragmas ...string) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + t.TempDir() + "/test.db"
+	if len(pragmas) != 0 {
+		dsn += "?_pragma=" + strings.Join(pragmas, "&_pragma=")
+	}
+
+	db, err := orm.Open(context.Background(), driver.SQLite, database.Address(dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { orm.Close(db) })
+	return db
+}
@@ -0,0 +1,318 @@
+package orm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrorInvalidSort 排序字段不在 Sortable 中
+	ErrorInvalidSort = errors.New("orm: invalid sort")
+	// ErrorInvalidCursor cursor 无效或与排序不匹配
+	ErrorInvalidCursor = errors.New("orm: invalid cursor")
+)
+
+// PageRequest 分页参数
+type PageRequest struct {
+	// Page 页码，从 1 开始。Cursor 分页时不生效
+	Page int `json:"page" form:"page"`
+	// Size 分页大小，为 0 时使用默认值，超过最大值时使用最大值
+	Size int `json:"size" form:"size"`
+	// Sort 排序，逗号分隔，- 开头为倒序。如: -created_at,name
+	Sort string `json:"sort" form:"sort"`
+	// Cursor 上一页返回的 NextCursor，为空时从第一页开始
+	Cursor string `json:"cursor" form:"cursor"`
+}
+
+// Page 分页结果
+type Page[T any] struct {
+	Items []*T `json:"items"`
+	// Total 总数。Cursor 分页时为 0
+	Total int64 `json:"total,omitempty"`
+	// Page 页码。Cursor 分页时为 0
+	Page int `json:"page,omitempty"`
+	Size int `json:"size"`
+	// HasMore 是否有下一页
+	HasMore bool `json:"has_more"`
+	// NextCursor 下一页的 cursor。Offset 分页时为空
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// order .
+type order struct {
+	field *schema.Field
+	desc  bool
+}
+
+// cursor .
+type cursor struct {
+	// Sort 生成 cursor 时的排序
+	Sort string `json:"s"`
+	// Values 上一页最后一条记录的排序字段值
+	Values []json.RawMessage `json:"v"`
+}
+
+// size .
+func (r *Repository[T]) size(size int) int {
+	switch {
+	case size <= 0:
+		return r.options.PageSize
+	case size > r.options.MaxPageSize:
+		return r.options.MaxPageSize
+	default:
+		return size
+	}
+}
+
+// orders 解析排序。未包含主键时追加主键正序，以保证顺序唯一，分页时不会重复或遗漏
+func (r *Repository[T]) orders(s *schema.Schema, sort string) ([]*order, error) {
+	var (
+		pk     = s.PrioritizedPrimaryField
+		orders = make([]*order, 0)
+		hasPK  bool
+	)
+
+	for _, item := range strings.Split(sort, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		var desc bool
+		if strings.HasPrefix(item, "-") {
+			item, desc = item[1:], true
+		}
+
+		field := s.LookUpField(item)
+		if field == nil || len(field.DBName) == 0 || !r.sortable(field) {
+			return nil, ErrorInvalidSort
+		}
+		hasPK = hasPK || field == pk
+		orders = append(orders, &order{field: field, desc: desc})
+	}
+
+	if !hasPK {
+		orders = append(orders, &order{field: pk})
+	}
+	return orders, nil
+}
+
+// sortable .
+func (r *Repository[T]) sortable(field *schema.Field) bool {
+	if field.PrimaryKey {
+		return true
+	}
+	for _, column := range r.options.Sortable {
+		if column == field.DBName || column == field.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// orderBy .
+func orderBy(orders []*order) clause.OrderBy {
+	var columns = make([]clause.OrderByColumn, 0, len(orders))
+	for _, o := range orders {
+		columns = append(columns, clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: o.field.DBName}, Desc: o.desc})
+	}
+	return clause.OrderBy{Columns: columns}
+}
+
+// Paginate offset 分页
+// 多个分片时每个分片查询前 page*size 条记录，合并排序后取当前页
+func (r *Repository[T]) Paginate(ctx context.Context, req *PageRequest, filters ...Filter) (*Page[T], error) {
+	s, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	orders, err := r.orders(s, req.Sort)
+	if err != nil {
+		return nil, err
+	}
+	shards, err := r.shards(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var page = &Page[T]{Page: req.Page, Size: r.size(req.Size), Items: make([]*T, 0)}
+	if page.Page < 1 {
+		page.Page = 1
+	}
+
+	if page.Total, err = r.Count(ctx, filters...); err != nil {
+		return nil, err
+	}
+
+	offset := (page.Page - 1) * page.Size
+	if int64(offset) < page.Total {
+		for _, shard := range shards {
+			db := r.scopes(r.conn(ctx, shard), filters).Clauses(orderBy(orders))
+			if len(shards) == 1 {
+				db = db.Offset(offset).Limit(page.Size)
+			} else {
+				db = db.Limit(offset + page.Size)
+			}
+
+			var items = make([]*T, 0)
+			if err := db.Find(&items).Error; err != nil {
+				return nil, err
+			}
+			page.Items = append(page.Items, items...)
+		}
+
+		if len(shards) != 1 {
+			merge(ctx, orders, page.Items)
+			page.Items = page.Items[min(offset, len(page.Items)):min(offset+page.Size, len(page.Items))]
+		}
+	}
+	page.HasMore = int64(offset+len(page.Items)) < page.Total
+	return page, nil
+}
+
+// Scroll keyset(cursor) 分页。排序字段不应为 NULL
+// 多个分片时每个分片查询 size+1 条记录，合并排序后取前 size 条
+func (r *Repository[T]) Scroll(ctx context.Context, req *PageRequest, filters ...Filter) (*Page[T], error) {
+	s, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	orders, err := r.orders(s, req.Sort)
+	if err != nil {
+		return nil, err
+	}
+	shards, err := r.shards(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	if len(req.Cursor) != 0 {
+		if values, err = decodeCursor(req.Cursor, req.Sort, orders); err != nil {
+			return nil, err
+		}
+	}
+
+	var page = &Page[T]{Size: r.size(req.Size), Items: make([]*T, 0)}
+	for _, shard := range shards {
+		db := r.scopes(r.conn(ctx, shard), filters).Clauses(orderBy(orders)).Limit(page.Size + 1)
+		if values != nil {
+			db = db.Where(after(orders, values))
+		}
+
+		var items = make([]*T, 0)
+		if err := db.Find(&items).Error; err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, items...)
+	}
+	if len(shards) != 1 {
+		merge(ctx, orders, page.Items)
+	}
+
+	if len(page.Items) > page.Size {
+		page.Items, page.HasMore = page.Items[:page.Size], true
+	}
+	if page.HasMore {
+		if page.NextCursor, err = encodeCursor(ctx, req.Sort, orders, page.Items[len(page.Items)-1]); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// merge 按排序合并多个分片的结果
+func merge[T any](ctx context.Context, orders []*order, items []*T) {
+	sort.SliceStable(items, func(i, j int) bool {
+		vi, vj := reflect.ValueOf(items[i]).Elem(), reflect.ValueOf(items[j]).Elem()
+		for _, o := range orders {
+			a, _ := o.field.ValueOf(ctx, vi)
+			b, _ := o.field.ValueOf(ctx, vj)
+			if c := compare(a, b); c != 0 {
+				return (c < 0) != o.desc
+			}
+		}
+		return false
+	})
+}
+
+// min .
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// after 排序在 values 之后的记录: (a > ?) OR (a = ? AND b > ?) ...
+func after(orders []*order, values []interface{}) clause.Expression {
+	var exprs = make([]clause.Expression, 0, len(orders))
+	for i, o := range orders {
+		var and = make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: orders[j].field.DBName}, Value: values[j]})
+		}
+
+		column := clause.Column{Table: clause.CurrentTable, Name: o.field.DBName}
+		if o.desc {
+			and = append(and, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: column, Value: values[i]})
+		}
+		exprs = append(exprs, clause.And(and...))
+	}
+	return clause.Or(exprs...)
+}
+
+// encodeCursor .
+func encodeCursor(ctx context.Context, sort string, orders []*order, item interface{}) (string, error) {
+	var (
+		rv = reflect.Indirect(reflect.ValueOf(item))
+		c  = &cursor{Sort: sort, Values: make([]json.RawMessage, 0, len(orders))}
+	)
+	for _, o := range orders {
+		val, _ := o.field.ValueOf(ctx, rv)
+		data, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, data)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 按排序字段的类型解析 cursor 中的值
+func decodeCursor(s string, sort string, orders []*order) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrorInvalidCursor
+	}
+
+	var c = new(cursor)
+	if err := json.Unmarshal(data, c); err != nil || c.Sort != sort || len(c.Values) != len(orders) {
+		return nil, ErrorInvalidCursor
+	}
+
+	var values = make([]interface{}, 0, len(orders))
+	for i, o := range orders {
+		rv := reflect.New(o.field.FieldType)
+		if err := json.Unmarshal(c.Values[i], rv.Interface()); err != nil {
+			return nil, ErrorInvalidCursor
+		}
+		values = append(values, rv.Elem().Interface())
+	}
+	return values, nil
+}
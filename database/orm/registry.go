@@ -0,0 +1,148 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/hfw/lifecycle"
+	"gorm.io/gorm"
+)
+
+// DefaultName Init、DB 使用的数据库名称
+const DefaultName = "default"
+
+var (
+	mu        sync.RWMutex
+	databases = map[string]*gorm.DB{}
+)
+
+// Register 连接并注册数据库，各数据库使用独立的连接池
+func Register(name string, fn driver.Dialector, opts ...database.Option) error {
+	return RegisterContext(context.Background(), name, fn, opts...)
+}
+
+// RegisterContext 同 Register，ctx 结束时停止重试
+func RegisterContext(ctx context.Context, name string, fn driver.Dialector, opts ...database.Option) error {
+	if _, err := Get(name); err == nil {
+		return fmt.Errorf("%w: %s", database.ErrorDatabaseExists, name)
+	}
+
+	// 连接时不持有锁，避免重试期间阻塞其他数据库
+	gormDB, err := Open(ctx, fn, opts...)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, found := databases[name]; found {
+		Close(gormDB)
+		return fmt.Errorf("%w: %s", database.ErrorDatabaseExists, name)
+	}
+	databases[name] = gormDB
+	return nil
+}
+
+// Get 获取已注册的数据库
+func Get(name string) (*gorm.DB, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if gormDB, found := databases[name]; found {
+		return gormDB, nil
+	}
+	return nil, fmt.Errorf("%w: %s", database.ErrorDatabaseNil, name)
+}
+
+// Names 已注册的数据库名称
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var names = make([]string, 0, len(databases))
+	for name := range databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Range 按名称顺序遍历已注册的数据库，fn 返回 false 时停止遍历
+func Range(fn func(name string, gormDB *gorm.DB) bool) {
+	for _, name := range Names() {
+		if gormDB, err := Get(name); err == nil && !fn(name, gormDB) {
+			return
+		}
+	}
+}
+
+// Deregister 注销并关闭数据库
+func Deregister(name string) error {
+	return DeregisterContext(context.Background(), name)
+}
+
+// DeregisterContext 注销并关闭数据库。关闭时等待执行中的 sql 完成，ctx 结束时不再等待(后台继续关闭)
+func DeregisterContext(ctx context.Context, name string) error {
+	mu.Lock()
+	gormDB, found := databases[name]
+	delete(databases, name)
+	mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("%w: %s", database.ErrorDatabaseNil, name)
+	}
+
+	var done = make(chan error, 1)
+	go func() {
+		done <- Close(gormDB)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("database: close %s. %w", name, ctx.Err())
+	}
+}
+
+// CloseAll 注销并关闭所有数据库，返回第一个错误
+func CloseAll() error {
+	var err error
+	for _, name := range Names() {
+		if e := Deregister(name); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Lifecycle 用于 lifecycle，启动时连接并注册数据库(根据 database.Retry 重试)，停止时等待执行中的 sql 完成后关闭
+func Lifecycle(name string, fn driver.Dialector, opts ...database.Option) *lifecycle.Hook {
+	return &lifecycle.Hook{
+		Name: "database:" + name,
+		OnStart: func(ctx context.Context) error {
+			return RegisterContext(ctx, name, fn, opts...)
+		},
+		OnStop: func(ctx context.Context) error {
+			return DeregisterContext(ctx, name)
+		},
+	}
+}
+
+// Hook 用于 lifecycle，停止时关闭所有数据库
+func Hook() *lifecycle.Hook {
+	return &lifecycle.Hook{
+		Name: "database",
+		OnStart: func(ctx context.Context) error {
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return CloseAll()
+		},
+	}
+}
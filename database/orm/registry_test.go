@@ -0,0 +1,54 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/hfw/lifecycle"
+)
+
+func TestRegistry(t *testing.T) {
+	root := t.TempDir()
+
+	if err := Init(driver.SQLite, database.Address("file:"+root+"/default.db")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(driver.SQLite); !errors.Is(err, database.ErrorDatabaseExists) {
+		t.Fatalf("expected ErrorDatabaseExists, got %v", err)
+	}
+	if err := Register("audit", driver.SQLite, database.Address("file:"+root+"/audit.db")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register("audit", driver.SQLite); !errors.Is(err, database.ErrorDatabaseExists) {
+		t.Fatalf("expected ErrorDatabaseExists, got %v", err)
+	}
+	if names := Names(); !reflect.DeepEqual(names, []string{"audit", DefaultName}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+
+	if _, err := DB(); err != nil {
+		t.Fatal(err)
+	}
+	audit, err := Get("audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := audit.DB()
+
+	lf := new(lifecycle.Lifecycle)
+	lf.Append(Hook())
+	if err := lf.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Get("audit"); !errors.Is(err, database.ErrorDatabaseNil) {
+		t.Fatalf("expected ErrorDatabaseNil, got %v", err)
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Fatal("expected closed database")
+	}
+}
@@ -0,0 +1,319 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// defaultPageSize 默认分页大小
+	defaultPageSize = 20
+	// defaultMaxPageSize 默认最大分页大小
+	defaultMaxPageSize = 100
+)
+
+// Filter 查询条件
+type Filter func(db *gorm.DB) *gorm.DB
+
+// Where .
+func Where(query interface{}, args ...interface{}) Filter {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// RepositoryOptions .
+type RepositoryOptions struct {
+	// Sortable 允许排序的列名，主键总是允许排序
+	Sortable []string
+	// PageSize 默认分页大小
+	PageSize int
+	// MaxPageSize 最大分页大小
+	MaxPageSize int
+	// Sharding 水平分片
+	Sharding *Sharding
+}
+
+type RepositoryOption func(o *RepositoryOptions)
+
+// Sortable .
+func Sortable(columns ...string) RepositoryOption {
+	return func(o *RepositoryOptions) {
+		o.Sortable = append(o.Sortable, columns...)
+	}
+}
+
+// PageSize .
+func PageSize(size, max int) RepositoryOption {
+	return func(o *RepositoryOptions) {
+		if size > 0 {
+			o.PageSize = size
+		}
+		if max > 0 {
+			o.MaxPageSize = max
+		}
+	}
+}
+
+// Sharded 按分片路由。写操作需确定分片(WithShardKey、分片键为主键、创建的记录中的分片键)
+// 读操作无法确定分片时查询所有分片并合并结果
+func Sharded(sharding *Sharding) RepositoryOption {
+	return func(o *RepositoryOptions) {
+		o.Sharding = sharding
+	}
+}
+
+// Repository 通用的增删改查。ctx 中有该数据库的事务(Tx)时在事务中执行
+type Repository[T any] struct {
+	db      *gorm.DB
+	options *RepositoryOptions
+}
+
+// NewRepository .
+func NewRepository[T any](gormDB *gorm.DB, opts ...RepositoryOption) *Repository[T] {
+	var options = &RepositoryOptions{PageSize: defaultPageSize, MaxPageSize: defaultMaxPageSize}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.PageSize > options.MaxPageSize {
+		options.PageSize = options.MaxPageSize
+	}
+
+	return &Repository[T]{db: gormDB, options: options}
+}
+
+// DB 返回 ctx 中的事务或 Repository 的数据库，用于自定义查询
+// 分片时使用 WithShardKey 指定的分片，未指定时使用 Repository 的数据库
+func (r *Repository[T]) DB(ctx context.Context) *gorm.DB {
+	if key, ok := shardKeyFrom(ctx); ok && r.options.Sharding != nil {
+		if shard, err := r.options.Sharding.Shard(key); err == nil {
+			return r.conn(ctx, shard)
+		}
+	}
+	return r.conn(ctx, nil)
+}
+
+// conn 分片对应的连接，shard 为 nil 时使用 Repository 的数据库
+func (r *Repository[T]) conn(ctx context.Context, shard *Shard) *gorm.DB {
+	if shard == nil {
+		return Conn(ctx, r.db).Model(new(T))
+	}
+
+	gormDB := r.db
+	if shard.DB != nil {
+		gormDB = shard.DB
+	}
+	db := Conn(ctx, gormDB).Model(new(T))
+	if r.outsideTx(ctx, gormDB) {
+		db.AddError(ErrorShardOutsideTx)
+	}
+	if len(shard.Suffix) != 0 {
+		if s, err := r.schema(); err == nil {
+			db = db.Table(s.Table + shard.Suffix)
+		}
+	}
+	return db
+}
+
+// outsideTx ctx 中存在 Repository 或其他分片数据库的事务，而 gormDB 不在事务中
+func (r *Repository[T]) outsideTx(ctx context.Context, gormDB *gorm.DB) bool {
+	if _, ok := txFrom(ctx, gormDB); ok {
+		return false
+	}
+	if _, ok := txFrom(ctx, r.db); ok {
+		return true
+	}
+	for _, shard := range r.options.Sharding.shards {
+		if _, ok := txFrom(ctx, shard.DB); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// shards 执行操作的分片。未分片时返回 [nil]
+// 分片键依次取自 WithShardKey、key，均为空时返回所有分片
+func (r *Repository[T]) shards(ctx context.Context, key interface{}) ([]*Shard, error) {
+	sharding := r.options.Sharding
+	if sharding == nil {
+		return []*Shard{nil}, nil
+	}
+
+	if k, ok := shardKeyFrom(ctx); ok {
+		key = k
+	}
+	if key == nil {
+		return sharding.shards, nil
+	}
+
+	shard, err := sharding.Shard(key)
+	if err != nil {
+		return nil, err
+	}
+	return []*Shard{shard}, nil
+}
+
+// shard 写操作的分片，无法确定分片时返回 ErrorShardKeyRequired
+func (r *Repository[T]) shard(ctx context.Context, key interface{}) (*Shard, error) {
+	shards, err := r.shards(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) != 1 {
+		return nil, ErrorShardKeyRequired
+	}
+	return shards[0], nil
+}
+
+// idKey 分片键为主键时，返回 id 作为分片键
+func (r *Repository[T]) idKey(id interface{}) interface{} {
+	if r.options.Sharding == nil {
+		return nil
+	}
+	if s, err := r.schema(); err == nil && s.PrioritizedPrimaryField.DBName == r.options.Sharding.column {
+		return id
+	}
+	return nil
+}
+
+// scopes .
+func (r *Repository[T]) scopes(db *gorm.DB, filters []Filter) *gorm.DB {
+	for _, filter := range filters {
+		if filter != nil {
+			db = filter(db)
+		}
+	}
+	return db
+}
+
+// schema .
+func (r *Repository[T]) schema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("orm: %s has no primary key", stmt.Schema.Name)
+	}
+	return stmt.Schema, nil
+}
+
+// Get 根据主键查询，不存在时返回 gorm.ErrRecordNotFound
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	shards, err := r.shards(ctx, r.idKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, shard := range shards {
+		var v = new(T)
+		err := r.conn(ctx, shard).Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).Take(v).Error
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// List 查询所有满足条件的记录
+func (r *Repository[T]) List(ctx context.Context, filters ...Filter) ([]*T, error) {
+	shards, err := r.shards(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list = make([]*T, 0)
+	for _, shard := range shards {
+		var items = make([]*T, 0)
+		if err := r.scopes(r.conn(ctx, shard), filters).Find(&items).Error; err != nil {
+			return nil, err
+		}
+		list = append(list, items...)
+	}
+	return list, nil
+}
+
+// Count .
+func (r *Repository[T]) Count(ctx context.Context, filters ...Filter) (int64, error) {
+	shards, err := r.shards(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, shard := range shards {
+		var count int64
+		if err := r.scopes(r.conn(ctx, shard), filters).Count(&count).Error; err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// Create .
+func (r *Repository[T]) Create(ctx context.Context, v *T) error {
+	var key interface{}
+	if r.options.Sharding != nil {
+		s, err := r.schema()
+		if err != nil {
+			return err
+		}
+		if field := s.LookUpField(r.options.Sharding.column); field != nil {
+			if val, zero := field.ValueOf(ctx, reflect.ValueOf(v).Elem()); !zero {
+				key = val
+			}
+		}
+	}
+
+	shard, err := r.shard(ctx, key)
+	if err != nil {
+		return err
+	}
+	return r.conn(ctx, shard).Create(v).Error
+}
+
+// Update 根据主键更新部分字段。fields 为 map[string]interface{} 或结构体(忽略零值字段)
+// 记录不存在时返回 gorm.ErrRecordNotFound
+func (r *Repository[T]) Update(ctx context.Context, id interface{}, fields interface{}) error {
+	shard, err := r.shard(ctx, r.idKey(id))
+	if err != nil {
+		return err
+	}
+
+	db := r.conn(ctx, shard).Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).Updates(fields)
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected != 0 {
+		return nil
+	}
+
+	// mysql 中值未变化时影响行数为 0，需确认记录是否存在
+	var count int64
+	if err := r.conn(ctx, shard).Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).Limit(1).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete 根据主键删除
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	shard, err := r.shard(ctx, r.idKey(id))
+	if err != nil {
+		return err
+	}
+	return r.conn(ctx, shard).Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).Delete(new(T)).Error
+}
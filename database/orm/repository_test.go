@@ -0,0 +1,120 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestRepository(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.AutoMigrate(new(User)); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		ctx  = context.Background()
+		repo = NewRepository[User](db, Sortable("name"))
+	)
+
+	for i := 0; i < 25; i++ {
+		// name 有重复，keyset 分页需按主键保证顺序唯一
+		if err := repo.Create(ctx, &User{Name: fmt.Sprintf("user-%d", i%5)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := repo.Update(ctx, 1, map[string]interface{}{"name": "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := repo.Get(ctx, 1)
+	if err != nil || user.Name != "admin" {
+		t.Fatalf("unexpected user: %v %v", user, err)
+	}
+
+	if err := repo.Update(ctx, 100, map[string]interface{}{"name": "admin"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Get(ctx, 1); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	if count, err := repo.Count(ctx, Where("name = ?", "user-1")); err != nil || count != 5 {
+		t.Fatalf("unexpected count: %d %v", count, err)
+	}
+
+	// offset
+	page, err := repo.Paginate(ctx, &PageRequest{Page: 3, Size: 10, Sort: "-id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 24 || len(page.Items) != 4 || page.HasMore || page.Items[0].ID != 5 || page.Items[3].ID != 2 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	// 排序字段有重复时按主键保证顺序唯一，分页不重复、不遗漏
+	var paged = map[int64]bool{}
+	for i := 1; i <= 3; i++ {
+		page, err := repo.Paginate(ctx, &PageRequest{Page: i, Size: 10, Sort: "name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, item := range page.Items {
+			if paged[item.ID] {
+				t.Fatalf("duplicate item %d", item.ID)
+			}
+			paged[item.ID] = true
+			if j != 0 && page.Items[j-1].Name == item.Name && page.Items[j-1].ID > item.ID {
+				t.Fatalf("unexpected order: %d after %d", item.ID, page.Items[j-1].ID)
+			}
+		}
+	}
+	if len(paged) != 24 {
+		t.Fatalf("expected 24 items, got %d", len(paged))
+	}
+
+	if _, err := repo.Paginate(ctx, &PageRequest{Sort: "password"}); !errors.Is(err, ErrorInvalidSort) {
+		t.Fatalf("expected ErrorInvalidSort, got %v", err)
+	}
+
+	// keyset
+	var (
+		seen = map[int64]bool{}
+		req  = &PageRequest{Size: 7, Sort: "-name"}
+		last string
+	)
+	for {
+		page, err := repo.Scroll(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range page.Items {
+			if seen[item.ID] {
+				t.Fatalf("duplicate item %d", item.ID)
+			}
+			if len(last) != 0 && item.Name > last {
+				t.Fatalf("unexpected order: %s after %s", item.Name, last)
+			}
+			seen[item.ID], last = true, item.Name
+		}
+		if !page.HasMore {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	if len(seen) != 24 {
+		t.Fatalf("expected 24 items, got %d", len(seen))
+	}
+
+	if _, err := repo.Scroll(ctx, &PageRequest{Sort: "name", Cursor: req.Cursor}); !errors.Is(err, ErrorInvalidCursor) {
+		t.Fatalf("expected ErrorInvalidCursor, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/logger"
+	"gorm.io/gorm"
+)
+
+// resolverName gorm plugin name
+const resolverName = "hfw:resolver"
+
+type primaryKey struct{}
+
+// WithPrimary 读操作强制使用主库，如: 写后立即读
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// usePrimary .
+func usePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	b, _ := ctx.Value(primaryKey{}).(bool)
+	return b
+}
+
+// replica 从库
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+	// latency ping 延迟(纳秒)
+	latency atomic.Int64
+}
+
+// resolver 读写分离。写操作及事务使用主库，读操作使用健康的从库
+type resolver struct {
+	driver   driver.Type
+	policy   string
+	replicas []*replica
+
+	next uint64
+	stop chan struct{}
+	once sync.Once
+}
+
+// newResolver 连接从库。从库连接失败时标记为不健康，由健康检查恢复
+func newResolver(ctx context.Context, fn driver.Dialector, options *database.Options) (*resolver, error) {
+	r := &resolver{
+		driver:   fn.Type(),
+		policy:   options.Policy,
+		replicas: make([]*replica, 0, len(options.Replicas)),
+		stop:     make(chan struct{}),
+	}
+
+	switch r.policy {
+	case "", database.PolicyRandom, database.PolicyRoundRobin, database.PolicyLeastLatency:
+	default:
+		return nil, fmt.Errorf("database: unknown replica policy %q", r.policy)
+	}
+
+	for _, addr := range options.Replicas {
+		opts := *options
+		opts.Address = addr
+
+		dialector, err := fn.Dialector(&opts)
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+
+		gormDB, err := gorm.Open(dialector, &gorm.Config{Logger: custom(fn.Type(), options), DisableAutomaticPing: true})
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+		db, err := gormDB.DB()
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+		configure(db, options)
+
+		rep := &replica{db: db}
+		if err := r.ping(ctx, rep, options.ConnectTimeout); err != nil {
+			logger.Warnf("[%s] >>> replica connect failed. %v", r.driver, err)
+		}
+		r.replicas = append(r.replicas, rep)
+	}
+
+	go r.healthCheck(options.HealthCheckInterval, options.ConnectTimeout)
+	return r, nil
+}
+
+// Name .
+func (r *resolver) Name() string {
+	return resolverName
+}
+
+// Initialize .
+func (r *resolver) Initialize(db *gorm.DB) error {
+	var errs = []error{
+		db.Callback().Create().Before("gorm:begin_transaction").Register(resolverName, r.switchPrimary),
+		db.Callback().Update().Before("gorm:begin_transaction").Register(resolverName, r.switchPrimary),
+		db.Callback().Delete().Before("gorm:begin_transaction").Register(resolverName, r.switchPrimary),
+		db.Callback().Query().Before("gorm:query").Register(resolverName, r.switchReplica),
+		db.Callback().Row().Before("gorm:row").Register(resolverName, r.switchReplica),
+		db.Callback().Raw().Before("gorm:raw").Register(resolverName, r.switchReplica),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	db.Dialector = &primaryDialector{Dialector: db.Dialector}
+	return nil
+}
+
+// switchPrimary 恢复主库。同一语句先读后写时，ConnPool 仍为读操作使用的从库
+// 写操作需在 gorm:begin_transaction 之前恢复，否则事务在从库开启
+func (r *resolver) switchPrimary(db *gorm.DB) {
+	stmt := db.Statement
+	for _, rep := range r.replicas {
+		if stmt.ConnPool == gorm.ConnPool(rep.db) {
+			stmt.ConnPool = db.Config.ConnPool
+			return
+		}
+	}
+}
+
+// switchReplica .
+func (r *resolver) switchReplica(db *gorm.DB) {
+	r.switchPrimary(db)
+	stmt := db.Statement
+
+	// 事务中
+	if _, ok := stmt.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if usePrimary(stmt.Context) {
+		return
+	}
+	// SELECT ... FOR UPDATE
+	if _, ok := stmt.Clauses["FOR"]; ok {
+		return
+	}
+	// db.Raw、db.Exec 仅 SELECT 语句使用从库
+	if stmt.SQL.Len() != 0 && !isSelect(stmt.SQL.String()) {
+		return
+	}
+
+	if rep := r.pick(); rep != nil {
+		stmt.ConnPool = rep.db
+	}
+}
+
+// primaryDialector Migrator(AutoMigrate 等)使用主库
+type primaryDialector struct {
+	gorm.Dialector
+}
+
+// Migrator .
+func (d *primaryDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return d.Dialector.Migrator(db.WithContext(WithPrimary(ctx)))
+}
+
+// Translate gorm.ErrorTranslator
+func (d *primaryDialector) Translate(err error) error {
+	if translator, ok := d.Dialector.(gorm.ErrorTranslator); ok {
+		return translator.Translate(err)
+	}
+	return err
+}
+
+// SavePoint gorm.SavePointerDialectorInterface
+func (d *primaryDialector) SavePoint(tx *gorm.DB, name string) error {
+	if savePointer, ok := d.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.SavePoint(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
+
+// RollbackTo gorm.SavePointerDialectorInterface
+func (d *primaryDialector) RollbackTo(tx *gorm.DB, name string) error {
+	if savePointer, ok := d.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.RollbackTo(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
+
+// isSelect .
+func isSelect(sql string) bool {
+	sql = strings.ToLower(strings.TrimSpace(sql))
+	return strings.HasPrefix(sql, "select") && !strings.Contains(sql, "for update")
+}
+
+// pick 根据策略选择健康的从库，无健康从库时返回 nil
+func (r *resolver) pick() *replica {
+	var healthy = make([]*replica, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			healthy = append(healthy, rep)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch r.policy {
+	case database.PolicyRoundRobin:
+		return healthy[(atomic.AddUint64(&r.next, 1)-1)%uint64(len(healthy))]
+	case database.PolicyLeastLatency:
+		var least = healthy[0]
+		for _, rep := range healthy[1:] {
+			if rep.latency.Load() < least.latency.Load() {
+				least = rep
+			}
+		}
+		return least
+	default:
+		return healthy[rand.Intn(len(healthy))]
+	}
+}
+
+// ping 更新从库健康状态及延迟
+func (r *resolver) ping(ctx context.Context, rep *replica, timeout time.Duration) error {
+	start := time.Now()
+	if err := ping(ctx, rep.db, timeout); err != nil {
+		if rep.healthy.Swap(false) {
+			logger.Warnf("[%s] >>> replica is unhealthy. %v", r.driver, err)
+		}
+		return err
+	}
+
+	latency := int64(time.Since(start))
+	if last := rep.latency.Load(); last != 0 {
+		// 平滑延迟，避免抖动
+		latency = (last*7 + latency*3) / 10
+	}
+	rep.latency.Store(latency)
+
+	if !rep.healthy.Swap(true) {
+		logger.Infof("[%s] >>> replica is healthy", r.driver)
+	}
+	return nil
+}
+
+// healthCheck .
+func (r *resolver) healthCheck(interval time.Duration, timeout time.Duration) {
+	if len(r.replicas) == 0 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			for _, rep := range r.replicas {
+				r.ping(context.Background(), rep, timeout)
+			}
+		}
+	}
+}
+
+// close 停止健康检查并关闭从库
+func (r *resolver) close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.stop)
+		for _, rep := range r.replicas {
+			if e := rep.db.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
+
+// Close 关闭数据库连接(包括从库)
+func Close(gormDB *gorm.DB) error {
+	if r, ok := gormDB.Config.Plugins[resolverName].(*resolver); ok {
+		if err := r.close(); err != nil {
+			return err
+		}
+	}
+
+	db, err := gormDB.DB()
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
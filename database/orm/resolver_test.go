@@ -0,0 +1,105 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"gorm.io/gorm"
+)
+
+// names .
+func names(t *testing.T, db *gorm.DB) []string {
+	var users []*User
+	if err := db.Order("id").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var names = make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names
+}
+
+func TestResolver(t *testing.T) {
+	var (
+		root    = t.TempDir()
+		primary = "file:" + root + "/primary.db"
+		replica = "file:" + root + "/replica.db"
+	)
+
+	// 从库数据
+	{
+		db, err := Open(context.Background(), driver.SQLite, database.Address(replica))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.AutoMigrate(new(User))
+		db.Create(&User{Name: "replica"})
+		Close(db)
+	}
+
+	db, err := Open(context.Background(), driver.SQLite,
+		database.Addrs(primary, replica),
+		database.Policy(database.PolicyRoundRobin),
+		database.HealthCheckInterval(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(db)
+
+	// 表结构查询使用主库
+	if err := db.AutoMigrate(new(User)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&User{Name: "primary"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if got := names(t, db); len(got) != 1 || got[0] != "replica" {
+		t.Fatalf("expected read from replica, got %v", got)
+	}
+	if got := names(t, db.WithContext(WithPrimary(context.Background()))); len(got) != 1 || got[0] != "primary" {
+		t.Fatalf("expected read from primary, got %v", got)
+	}
+
+	// 同一语句先读后写，写操作使用主库
+	query := db.Where("id = ?", 1)
+	var users []*User
+	if err := query.Find(&users).Error; err != nil || len(users) != 1 || users[0].Name != "replica" {
+		t.Fatalf("expected read from replica, got %v %v", users, err)
+	}
+	if err := query.Delete(new(User)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := names(t, db.WithContext(WithPrimary(context.Background()))); len(got) != 0 {
+		t.Fatalf("expected deleted from primary, got %v", got)
+	}
+	if got := names(t, db); len(got) != 1 || got[0] != "replica" {
+		t.Fatalf("expected replica unchanged, got %v", got)
+	}
+	if err := db.Create(&User{Name: "primary"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 事务使用主库
+	db.Transaction(func(tx *gorm.DB) error {
+		if got := names(t, tx); len(got) != 1 || got[0] != "primary" {
+			t.Fatalf("expected read from primary in transaction, got %v", got)
+		}
+		return nil
+	})
+
+	// 从库不可用时使用主库
+	r := db.Config.Plugins[resolverName].(*resolver)
+	r.replicas[0].db.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if got := names(t, db); len(got) != 1 || got[0] != "primary" {
+		t.Fatalf("expected read from primary, got %v", got)
+	}
+}
@@ -0,0 +1,209 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrorShardKeyRequired 写操作无法确定分片
+	ErrorShardKeyRequired = errors.New("orm: shard key required")
+	// ErrorShardOutOfRange 分片策略返回的分片不存在
+	ErrorShardOutOfRange = errors.New("orm: shard out of range")
+	// ErrorShardOutsideTx 事务中访问了其他数据库的分片
+	ErrorShardOutsideTx = errors.New("orm: shard outside transaction")
+)
+
+// Strategy 分片策略，根据分片键返回分片下标 [0, shards)
+type Strategy func(key interface{}, shards int) (int, error)
+
+// HashShard 按分片键的 fnv 哈希取模
+// 整型按十进制字符串哈希，int、int64、string 及其指针类型的相同值位于同一分片
+func HashShard() Strategy {
+	return func(key interface{}, shards int) (int, error) {
+		var s string
+		switch rv := reflect.Indirect(reflect.ValueOf(key)); rv.Kind() {
+		case reflect.Invalid:
+			return 0, fmt.Errorf("orm: hash shard of %T", key)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s = strconv.FormatInt(rv.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = strconv.FormatUint(rv.Uint(), 10)
+		case reflect.String:
+			s = rv.String()
+		default:
+			s = fmt.Sprint(rv.Interface())
+		}
+
+		h := fnv.New32a()
+		h.Write([]byte(s))
+		return int(h.Sum32() % uint32(shards)), nil
+	}
+}
+
+// RangeShard 按整型分片键的范围分片，bounds 为升序的上界(不包含)
+// 如: RangeShard(1000, 2000) 对应 3 个分片: (, 1000) [1000, 2000) [2000, )
+func RangeShard(bounds ...int64) Strategy {
+	return func(key interface{}, shards int) (int, error) {
+		if len(bounds)+1 != shards {
+			return 0, fmt.Errorf("%w: %d bounds of %d shards", ErrorShardOutOfRange, len(bounds), shards)
+		}
+
+		rv := reflect.Indirect(reflect.ValueOf(key))
+		var n int64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = int64(rv.Uint())
+		default:
+			return 0, fmt.Errorf("orm: range shard of %T", key)
+		}
+		return sort.Search(len(bounds), func(i int) bool { return n < bounds[i] }), nil
+	}
+}
+
+// Shard 分片
+type Shard struct {
+	// DB 分片所在的数据库，为空时使用 Repository 的数据库
+	DB *gorm.DB
+	// Suffix 表名后缀，如: _00
+	Suffix string
+}
+
+// DatabaseShards 使用已注册的数据库作为分片
+// 事务只对开启事务的数据库生效，事务中访问其他数据库的分片返回 ErrorShardOutsideTx
+func DatabaseShards(names ...string) ([]*Shard, error) {
+	var shards = make([]*Shard, 0, len(names))
+	for _, name := range names {
+		gormDB, err := Get(name)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, &Shard{DB: gormDB})
+	}
+	return shards, nil
+}
+
+// TableShards 同一数据库中的 n 张分表，后缀为 fmt.Sprintf(format, i)，如: TableShards(4, "_%02d")
+func TableShards(n int, format string) []*Shard {
+	var shards = make([]*Shard, 0, n)
+	for i := 0; i < n; i++ {
+		shards = append(shards, &Shard{Suffix: fmt.Sprintf(format, i)})
+	}
+	return shards
+}
+
+// Sharding 水平分片
+type Sharding struct {
+	// column 分片键列名
+	column   string
+	strategy Strategy
+	shards   []*Shard
+}
+
+// NewSharding column 为分片键列名
+func NewSharding(column string, strategy Strategy, shards ...*Shard) (*Sharding, error) {
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("orm: sharding of %s without shards", column)
+	}
+	return &Sharding{column: column, strategy: strategy, shards: shards}, nil
+}
+
+// Shard 分片键对应的分片
+func (s *Sharding) Shard(key interface{}) (*Shard, error) {
+	i, err := s.strategy(key, len(s.shards))
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(s.shards) {
+		return nil, fmt.Errorf("%w: %d of %v", ErrorShardOutOfRange, i, key)
+	}
+	return s.shards[i], nil
+}
+
+type shardKey struct{}
+
+// WithShardKey 指定分片键，Repository 的操作仅在该分片中执行
+func WithShardKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, shardKey{}, key)
+}
+
+// shardKeyFrom .
+func shardKeyFrom(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	key := ctx.Value(shardKey{})
+	return key, key != nil
+}
+
+// compare 比较排序字段的值，用于合并多个分片的结果
+func compare(a, b interface{}) int {
+	va, vb := reflect.Indirect(reflect.ValueOf(a)), reflect.Indirect(reflect.ValueOf(b))
+	if !va.IsValid() || !vb.IsValid() {
+		switch {
+		case va.IsValid():
+			return 1
+		case vb.IsValid():
+			return -1
+		default:
+			return 0
+		}
+	}
+
+	if ta, ok := va.Interface().(time.Time); ok {
+		if tb, ok := vb.Interface().(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp(va.Int(), vb.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp(va.Uint(), vb.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp(va.Float(), vb.Float())
+	case reflect.Bool:
+		return cmp(boolInt(va.Bool()), boolInt(vb.Bool()))
+	default:
+		return strings.Compare(fmt.Sprint(va.Interface()), fmt.Sprint(vb.Interface()))
+	}
+}
+
+// cmp .
+func cmp[N int64 | uint64 | float64](a, b N) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// boolInt .
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
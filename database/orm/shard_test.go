@@ -0,0 +1,215 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"gorm.io/gorm"
+)
+
+func TestSharding(t *testing.T) {
+	db := openTestDB(t)
+
+	// users_0: (, 10) users_1: [10, 20) users_2: [20, )
+	sharding, err := NewSharding("id", RangeShard(10, 20), TableShards(3, "_%d")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"users_0", "users_1", "users_2"} {
+		if err := db.Table(table).AutoMigrate(new(User)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		ctx  = context.Background()
+		repo = NewRepository[User](db, Sortable("name"), Sharded(sharding))
+	)
+
+	if err := repo.Create(ctx, &User{Name: "nobody"}); !errors.Is(err, ErrorShardKeyRequired) {
+		t.Fatalf("expected ErrorShardKeyRequired, got %v", err)
+	}
+	var names = make(map[int64]string)
+	for i := int64(1); i <= 25; i++ {
+		names[i] = fmt.Sprintf("user%d", i%3)
+		if err := repo.Create(ctx, &User{ID: i, Name: names[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int64
+	if err := db.Table("users_1").Count(&count).Error; err != nil || count != 10 {
+		t.Fatalf("unexpected count of users_1: %d %v", count, err)
+	}
+
+	if err := repo.Update(ctx, 15, map[string]interface{}{"name": "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := repo.Get(ctx, 15)
+	if err != nil || user.Name != "admin" {
+		t.Fatalf("unexpected user: %v %v", user, err)
+	}
+	if err := repo.Delete(ctx, 25); err != nil {
+		t.Fatal(err)
+	}
+	names[15] = "admin"
+	delete(names, 25)
+	if _, err := repo.Get(ctx, 25); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	// fan-out
+	if count, err := repo.Count(ctx); err != nil || count != 24 {
+		t.Fatalf("unexpected count: %d %v", count, err)
+	}
+	if list, err := repo.List(WithShardKey(ctx, 3)); err != nil || len(list) != 9 {
+		t.Fatalf("unexpected list: %d %v", len(list), err)
+	}
+
+	page, err := repo.Paginate(ctx, &PageRequest{Page: 2, Size: 10, Sort: "-id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 24 || len(page.Items) != 10 || !page.HasMore || page.Items[0].ID != 14 || page.Items[9].ID != 5 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	// 排序字段相同时按主键排序，各分片的记录交错
+	var ids = make([]int64, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if names[ids[i]] != names[ids[j]] {
+			return names[ids[i]] < names[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	for i := 1; i <= 3; i++ {
+		page, err := repo.Paginate(ctx, &PageRequest{Page: i, Size: 10, Sort: "name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := ids[(i-1)*10:]
+		if len(expected) > 10 {
+			expected = expected[:10]
+		}
+		if len(page.Items) != len(expected) {
+			t.Fatalf("page %d: expected %d items, got %d", i, len(expected), len(page.Items))
+		}
+		for j, item := range page.Items {
+			if item.ID != expected[j] {
+				t.Fatalf("page %d: expected %v, got item %d at %d", i, expected, item.ID, j)
+			}
+		}
+	}
+
+	var (
+		req  = &PageRequest{Size: 7}
+		last int64
+	)
+	for {
+		page, err := repo.Scroll(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range page.Items {
+			if item.ID <= last {
+				t.Fatalf("unexpected order: %d after %d", item.ID, last)
+			}
+			last = item.ID
+		}
+		if !page.HasMore {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	if last != 24 {
+		t.Fatalf("expected last item 24, got %d", last)
+	}
+
+	// 分片键不是主键时，更新需指定分片键
+	byName, err := NewSharding("name", HashShard(), TableShards(3, "_%d")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRepository[User](db, Sharded(byName)).Update(ctx, 1, map[string]interface{}{"name": "x"}); !errors.Is(err, ErrorShardKeyRequired) {
+		t.Fatalf("expected ErrorShardKeyRequired, got %v", err)
+	}
+}
+
+func TestHashShard(t *testing.T) {
+	var (
+		strategy = HashShard()
+		n        = int64(7)
+		s        = "7"
+	)
+
+	expected, err := strategy(7, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []interface{}{int32(7), int64(7), uint(7), "7", &n, &s} {
+		if i, err := strategy(key, 16); err != nil || i != expected {
+			t.Fatalf("%T: expected shard %d, got %d %v", key, expected, i, err)
+		}
+	}
+	if _, err := strategy((*int64)(nil), 16); err == nil {
+		t.Fatal("expected error of nil key")
+	}
+}
+
+func TestShardingTx(t *testing.T) {
+	var dbs = make([]*gorm.DB, 2)
+	for i := range dbs {
+		db, err := Open(context.Background(), driver.SQLite, database.Address(fmt.Sprintf("file:%s/%d.db", t.TempDir(), i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer Close(db)
+
+		if err := db.AutoMigrate(new(User)); err != nil {
+			t.Fatal(err)
+		}
+		dbs[i] = db
+	}
+
+	sharding, err := NewSharding("id", RangeShard(10), &Shard{DB: dbs[0]}, &Shard{DB: dbs[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		ctx  = context.Background()
+		repo = NewRepository[User](dbs[0], Sharded(sharding))
+	)
+
+	// 事务中只能访问开启事务的数据库的分片
+	err = Tx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := repo.Create(ctx, &User{ID: 1}); err != nil {
+			return err
+		}
+		return repo.Create(ctx, &User{ID: 11})
+	}, TxDB(dbs[0]))
+	if !errors.Is(err, ErrorShardOutsideTx) {
+		t.Fatalf("expected ErrorShardOutsideTx, got %v", err)
+	}
+	if count, err := repo.Count(ctx); err != nil || count != 0 {
+		t.Fatalf("unexpected count: %d %v", count, err)
+	}
+
+	if err := repo.Create(ctx, &User{ID: 11}); err != nil {
+		t.Fatal(err)
+	}
+	err = Tx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		return repo.Update(ctx, 11, map[string]interface{}{"name": "x"})
+	}, TxDB(dbs[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
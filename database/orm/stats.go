@@ -0,0 +1,55 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/charlesbases/logger"
+	"gorm.io/gorm"
+)
+
+// defaultStatsInterval 默认连接池状态上报间隔
+const defaultStatsInterval = time.Minute
+
+// Stats 连接池状态
+func Stats(gormDB *gorm.DB) (sql.DBStats, error) {
+	db, err := gormDB.DB()
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+	return db.Stats(), nil
+}
+
+// ReportStats 定时上报已注册数据库的连接池状态，ctx 结束时停止
+// interval 不大于 0 时使用 defaultStatsInterval，fn 为 nil 时输出日志
+func ReportStats(ctx context.Context, interval time.Duration, fn func(name string, stats sql.DBStats)) {
+	if interval <= 0 {
+		interval = defaultStatsInterval
+	}
+	if fn == nil {
+		fn = func(name string, stats sql.DBStats) {
+			logger.Debugf("[%s] >>> open: %d | in use: %d | idle: %d | wait: %d(%v)",
+				name, stats.OpenConnections, stats.InUse, stats.Idle, stats.WaitCount, stats.WaitDuration)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				Range(func(name string, gormDB *gorm.DB) bool {
+					if stats, err := Stats(gormDB); err == nil {
+						fn(name, stats)
+					}
+					return true
+				})
+			}
+		}
+	}()
+}
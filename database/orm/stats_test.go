@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+)
+
+func TestOptions(t *testing.T) {
+	db := openTestDB(t,
+		database.MaxOpenConns(4),
+		database.MaxIdleConns(2),
+		database.ConnMaxIdleTime(time.Minute),
+		database.QueryTimeout(time.Nanosecond),
+		database.PrepareStmt(true),
+	)
+
+	stats, err := Stats(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.MaxOpenConnections != 4 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	// sql 执行超时
+	if err := db.Exec("SELECT 1").Error; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	// context 已设置 deadline 时不生效
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := db.WithContext(ctx).Exec("SELECT 1").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReportStats(t *testing.T) {
+	if err := Register(t.Name(), driver.SQLite, database.Address("file:"+t.TempDir()+"/test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer Deregister(t.Name())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 无效的间隔使用默认值
+	ReportStats(ctx, 0, nil)
+
+	reports := make(chan string, 16)
+	ReportStats(ctx, 10*time.Millisecond, func(name string, stats sql.DBStats) {
+		select {
+		case reports <- name:
+		default:
+		}
+	})
+
+	select {
+	case name := <-reports:
+		if name != t.Name() {
+			t.Fatalf("unexpected name: %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,239 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/charlesbases/hfw/metadata"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tenantName gorm plugin name
+const tenantName = "hfw:tenant"
+
+// columnTenantID 租户列
+const columnTenantID = "tenant_id"
+
+var (
+	// ErrorTenantRequired context 中没有租户
+	ErrorTenantRequired = errors.New("orm: tenant required")
+	// ErrorTenantMismatch 写入的租户与 context 中的租户不一致
+	ErrorTenantMismatch = errors.New("orm: tenant mismatch")
+	// ErrorTenantUpsert Tenanted 模型不支持 ON CONFLICT DO UPDATE(冲突的记录可能属于其他租户)
+	ErrorTenantUpsert = errors.New("orm: upsert of tenanted model")
+)
+
+// tenantSchemaRegexp 租户 schema 名称只允许字母、数字、下划线
+var tenantSchemaRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Tenanted 实现该接口的模型按租户(tenant_id 列)隔离
+type Tenanted interface {
+	Tenanted()
+}
+
+type withoutTenantKey struct{}
+
+// WithoutTenant 不按租户隔离，用于管理后台等跨租户查询
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutTenantKey{}, true)
+}
+
+// withoutTenant .
+func withoutTenant(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	b, _ := ctx.Value(withoutTenantKey{}).(bool)
+	return b
+}
+
+// tenant 多租户。租户为 context 中 metadata.Metadata 的 key 对应的值
+//   - schema 为空时: 查询、更新、删除添加 WHERE tenant_id = ?，创建时填充 tenant_id。
+//     更新不允许修改 tenant_id，创建不允许 ON CONFLICT DO UPDATE(包括 Save 不存在的主键)
+//   - schema 不为空时: 表名替换为 fmt.Sprintf(schema, tenant) + "." + 表名，如: postgres 中每个租户使用独立的 schema
+//
+// Raw、Exec 及 Joins 关联的表不按租户隔离
+type tenant struct {
+	key    string
+	schema string
+}
+
+// Name .
+func (t *tenant) Name() string {
+	return tenantName
+}
+
+// Initialize 在其他插件(如 audit)之前执行
+func (t *tenant) Initialize(db *gorm.DB) error {
+	var errs = []error{
+		db.Callback().Create().Before("*").Register(tenantName, t.create),
+		db.Callback().Query().Before("*").Register(tenantName, t.scope),
+		db.Callback().Row().Before("*").Register(tenantName, t.scope),
+		db.Callback().Update().Before("*").Register(tenantName, t.update),
+		db.Callback().Delete().Before("*").Register(tenantName, t.scope),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenant 返回 context 中的租户。非 Tenanted 模型或 WithoutTenant 时返回 false
+func (t *tenant) tenant(db *gorm.DB) (interface{}, bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || withoutTenant(stmt.Context) {
+		return nil, false
+	}
+	if _, ok := reflect.New(stmt.Schema.ModelType).Interface().(Tenanted); !ok {
+		return nil, false
+	}
+
+	var id interface{}
+	if stmt.Context != nil {
+		id = metadata.Value(stmt.Context, t.key)
+	}
+	if id == nil {
+		db.AddError(fmt.Errorf("%w: %s", ErrorTenantRequired, stmt.Schema.Table))
+		return nil, false
+	}
+	return id, true
+}
+
+// table schema 模式下替换表名
+func (t *tenant) table(db *gorm.DB, id interface{}) bool {
+	name := fmt.Sprintf(t.schema, id)
+	if !tenantSchemaRegexp.MatchString(name) {
+		db.AddError(fmt.Errorf("orm: invalid tenant schema %q", name))
+		return false
+	}
+	db.Statement.Table = name + "." + db.Statement.Schema.Table
+	return true
+}
+
+// scope .
+func (t *tenant) scope(db *gorm.DB) {
+	id, ok := t.tenant(db)
+	if !ok {
+		return
+	}
+
+	if len(t.schema) != 0 {
+		t.table(db, id)
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: columnTenantID}, Value: id},
+	}})
+}
+
+// update 添加租户条件，并检查更新的 tenant_id
+func (t *tenant) update(db *gorm.DB) {
+	id, ok := t.tenant(db)
+	if !ok {
+		return
+	}
+
+	if len(t.schema) != 0 {
+		t.table(db, id)
+		return
+	}
+
+	stmt := db.Statement
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: columnTenantID}, Value: id},
+	}})
+
+	mismatch := func(val interface{}) {
+		if fmt.Sprint(val) != fmt.Sprint(id) {
+			db.AddError(fmt.Errorf("%w: %v", ErrorTenantMismatch, val))
+		}
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		// Updates(map)、Update(column, value)
+		for key, val := range dest {
+			if field := stmt.Schema.LookUpField(key); field != nil && field.DBName == columnTenantID {
+				mismatch(val)
+			}
+		}
+	default:
+		// Updates(struct)、Save(struct)
+		field := stmt.Schema.LookUpField(columnTenantID)
+		rv := reflect.Indirect(reflect.ValueOf(dest))
+		if field == nil || rv.Kind() != reflect.Struct || rv.Type() != stmt.Schema.ModelType {
+			break
+		}
+		switch val, zero := field.ValueOf(stmt.Context, rv); {
+		case zero && rv.CanAddr():
+			db.AddError(field.Set(stmt.Context, rv, id))
+		case zero && len(stmt.Selects) == 0:
+			// Updates(struct) 不更新零值
+		default:
+			mismatch(val)
+		}
+	}
+
+	if c, found := stmt.Clauses["SET"]; found {
+		if set, ok := c.Expression.(clause.Set); ok {
+			for _, assignment := range set {
+				if assignment.Column.Name == columnTenantID {
+					mismatch(assignment.Value)
+				}
+			}
+		}
+	}
+}
+
+// create .
+func (t *tenant) create(db *gorm.DB) {
+	id, ok := t.tenant(db)
+	if !ok {
+		return
+	}
+
+	if len(t.schema) != 0 {
+		t.table(db, id)
+		return
+	}
+
+	stmt := db.Statement
+	if c, found := stmt.Clauses["ON CONFLICT"]; found {
+		if conflict, ok := c.Expression.(clause.OnConflict); ok && !conflict.DoNothing {
+			db.AddError(fmt.Errorf("%w: %s", ErrorTenantUpsert, stmt.Schema.Table))
+			return
+		}
+	}
+
+	field := stmt.Schema.LookUpField(columnTenantID)
+	if field == nil {
+		db.AddError(fmt.Errorf("orm: %s has no %s column", stmt.Schema.Name, columnTenantID))
+		return
+	}
+
+	set := func(rv reflect.Value) {
+		val, zero := field.ValueOf(stmt.Context, rv)
+		if zero {
+			db.AddError(field.Set(stmt.Context, rv, id))
+			return
+		}
+		if fmt.Sprint(val) != fmt.Sprint(id) {
+			db.AddError(fmt.Errorf("%w: %v", ErrorTenantMismatch, val))
+		}
+	}
+
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
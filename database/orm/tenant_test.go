@@ -0,0 +1,135 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/hfw/metadata"
+	"gorm.io/gorm"
+)
+
+type Order struct {
+	ID       int64
+	TenantID string
+	Amount   int64
+}
+
+func (*Order) Tenanted() {}
+
+func TestTenant(t *testing.T) {
+	db := openTestDB(t, database.TenantKey("tenant"))
+
+	if err := db.AutoMigrate(new(Order)); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		a    = metadata.Metadata{"tenant": "a"}.WithContext(context.Background())
+		b    = metadata.Metadata{"tenant": "b"}.WithContext(context.Background())
+		repo = NewRepository[Order](db)
+	)
+
+	for _, ctx := range []context.Context{a, a, b} {
+		if err := repo.Create(ctx, &Order{Amount: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Create(a, &Order{TenantID: "b"}); !errors.Is(err, ErrorTenantMismatch) {
+		t.Fatalf("expected ErrorTenantMismatch, got %v", err)
+	}
+
+	if count, _ := repo.Count(a); count != 2 {
+		t.Fatalf("expected 2 orders of tenant a, got %d", count)
+	}
+	if _, err := repo.Get(b, 1); err == nil {
+		t.Fatal("expected order 1 invisible to tenant b")
+	}
+
+	// 跨租户更新无效
+	if err := repo.Update(b, 1, map[string]interface{}{"amount": 100}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+	if order, _ := repo.Get(a, 1); order.Amount != 1 {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+
+	// 不允许修改租户
+	for _, fields := range []map[string]interface{}{{"tenant_id": "b"}, {"TenantID": "b"}} {
+		if err := repo.Update(a, 1, fields); !errors.Is(err, ErrorTenantMismatch) {
+			t.Fatalf("expected ErrorTenantMismatch, got %v", err)
+		}
+	}
+	if err := repo.Update(a, 1, &Order{TenantID: "b", Amount: 2}); !errors.Is(err, ErrorTenantMismatch) {
+		t.Fatalf("expected ErrorTenantMismatch, got %v", err)
+	}
+	if err := repo.Update(a, 1, map[string]interface{}{"tenant_id": "a", "amount": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save 当前租户的记录时填充 tenant_id
+	if err := db.WithContext(a).Save(&Order{ID: 1, Amount: 3}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if order, err := repo.Get(a, 1); err != nil || order.TenantID != "a" || order.Amount != 3 {
+		t.Fatalf("unexpected order: %+v %v", order, err)
+	}
+	// Save 其他租户的记录时不会回退为 upsert
+	if err := db.WithContext(a).Save(&Order{ID: 3, Amount: 100}).Error; !errors.Is(err, ErrorTenantUpsert) {
+		t.Fatalf("expected ErrorTenantUpsert, got %v", err)
+	}
+	if order, err := repo.Get(b, 3); err != nil || order.TenantID != "b" || order.Amount != 1 {
+		t.Fatalf("unexpected order: %+v %v", order, err)
+	}
+
+	if _, err := repo.List(context.Background()); !errors.Is(err, ErrorTenantRequired) {
+		t.Fatalf("expected ErrorTenantRequired, got %v", err)
+	}
+	if count, _ := repo.Count(WithoutTenant(context.Background())); count != 3 {
+		t.Fatalf("expected 3 orders, got %d", count)
+	}
+}
+
+func TestTenantSchema(t *testing.T) {
+	root := t.TempDir()
+
+	db, err := Open(context.Background(), driver.SQLite, database.Address("file:"+root+"/test.db"),
+		database.TenantKey("tenant"), database.TenantSchema("tenant_%v"), database.MaxOpenConns(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(db)
+
+	// sqlite 中 schema 为 ATTACH 的数据库，仅对当前连接有效
+	for _, name := range []string{"a", "b"} {
+		if err := db.Exec("ATTACH DATABASE ? AS ?", root+"/"+name+".db", "tenant_"+name).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec("CREATE TABLE tenant_" + name + ".orders (id INTEGER PRIMARY KEY, tenant_id TEXT, amount INTEGER)").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		a    = metadata.Metadata{"tenant": "a"}.WithContext(context.Background())
+		b    = metadata.Metadata{"tenant": "b"}.WithContext(context.Background())
+		repo = NewRepository[Order](db)
+	)
+
+	if err := repo.Create(a, &Order{Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := repo.Count(a); count != 1 {
+		t.Fatalf("expected 1 order of tenant a, got %d", count)
+	}
+	if count, _ := repo.Count(b); count != 0 {
+		t.Fatalf("expected 0 order of tenant b, got %d", count)
+	}
+
+	c := metadata.Metadata{"tenant": "c;drop"}.WithContext(context.Background())
+	if _, err := repo.Count(c); err == nil {
+		t.Fatal("expected invalid tenant schema")
+	}
+}
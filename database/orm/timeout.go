@@ -0,0 +1,70 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// timeoutName gorm plugin name
+	timeoutName = "hfw:timeout"
+	// timeoutCancel 保存 context.CancelFunc 的 key
+	timeoutCancel = "hfw:timeout:cancel"
+)
+
+// timeout sql 执行超时。context 已设置 deadline 时不生效
+// db.Rows、db.Row 返回后仍需读取数据，不设置超时
+type timeout struct {
+	timeout time.Duration
+}
+
+// Name .
+func (t *timeout) Name() string {
+	return timeoutName
+}
+
+// Initialize .
+func (t *timeout) Initialize(db *gorm.DB) error {
+	var errs = []error{
+		db.Callback().Create().Before("gorm:create").Register(timeoutName+":before", t.before),
+		db.Callback().Create().After("gorm:create").Register(timeoutName+":after", t.after),
+		db.Callback().Query().Before("gorm:query").Register(timeoutName+":before", t.before),
+		db.Callback().Query().After("gorm:query").Register(timeoutName+":after", t.after),
+		db.Callback().Update().Before("gorm:update").Register(timeoutName+":before", t.before),
+		db.Callback().Update().After("gorm:update").Register(timeoutName+":after", t.after),
+		db.Callback().Delete().Before("gorm:delete").Register(timeoutName+":before", t.before),
+		db.Callback().Delete().After("gorm:delete").Register(timeoutName+":after", t.after),
+		db.Callback().Raw().Before("gorm:raw").Register(timeoutName+":before", t.before),
+		db.Callback().Raw().After("gorm:raw").Register(timeoutName+":after", t.after),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before .
+func (t *timeout) before(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(timeoutCancel, cancel)
+}
+
+// after .
+func (t *timeout) after(db *gorm.DB) {
+	if cancel, ok := db.InstanceGet(timeoutCancel); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
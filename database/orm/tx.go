@@ -0,0 +1,198 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/charlesbases/hfw/database/orm/driver"
+	"github.com/charlesbases/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultTxRetryBackoff 默认事务重试等待时间
+	defaultTxRetryBackoff = 10 * time.Millisecond
+	// maxTxRetryBackoff 最大事务重试等待时间
+	maxTxRetryBackoff = time.Second
+)
+
+type (
+	// txKey 事务按连接池(*sql.DB)保存，不同数据库的事务互不影响
+	txKey struct {
+		pool interface{}
+	}
+	attemptKey struct{}
+)
+
+// RetryError 事务重试次数用尽
+type RetryError struct {
+	// Attempts 执行次数
+	Attempts int
+	// Err last error
+	Err error
+}
+
+// Error .
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("database: transaction failed after %d attempts. %v", e.Attempts, e.Err)
+}
+
+// Unwrap .
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// TxOptions .
+type TxOptions struct {
+	// DB 开启事务的数据库，为空时使用 DefaultName 对应的数据库
+	DB *gorm.DB
+	// Isolation 事务隔离级别。嵌套事务中不生效
+	Isolation sql.IsolationLevel
+	// ReadOnly 只读事务。嵌套事务中不生效
+	ReadOnly bool
+	// Retry 死锁、序列化失败(driver.Retryable)时的重试次数。嵌套事务中不生效
+	Retry int
+	// RetryBackoff 首次重试的等待时间，之后每次翻倍(附加随机抖动)，最大为 maxTxRetryBackoff
+	RetryBackoff time.Duration
+}
+
+type TxOption func(o *TxOptions)
+
+// TxDB .
+func TxDB(gormDB *gorm.DB) TxOption {
+	return func(o *TxOptions) {
+		o.DB = gormDB
+	}
+}
+
+// Isolation .
+func Isolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// ReadOnly .
+func ReadOnly(b bool) TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = b
+	}
+}
+
+// TxRetry 死锁、序列化失败时重新执行事务，最多重试 n 次
+// fn 会被多次执行，不应包含事务外的副作用
+func TxRetry(n int, backoff time.Duration) TxOption {
+	return func(o *TxOptions) {
+		o.Retry = n
+		if backoff > 0 {
+			o.RetryBackoff = backoff
+		}
+	}
+}
+
+// backoff 第 attempt(从 1 开始)次重试前的等待时间，在 [d/2, d) 内随机
+func (o *TxOptions) backoff(attempt int) time.Duration {
+	d := o.RetryBackoff
+	for i := 1; i < attempt && d < maxTxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxTxRetryBackoff {
+		d = maxTxRetryBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Tx 在事务中执行 fn，fn 返回 error 或 panic 时回滚(panic 会在回滚后重新抛出)
+// 事务保存在 ctx 中，fn 中对同一数据库再次调用 Tx 时使用 savepoint 嵌套，内层回滚不影响外层
+// 对其他数据库调用 Tx 时开启该数据库的独立事务
+//
+//	orm.Tx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+//		return orm.Tx(ctx, func(ctx context.Context, tx *gorm.DB) error { ... })
+//	})
+func Tx(ctx context.Context, fn func(ctx context.Context, tx *gorm.DB) error, opts ...TxOption) error {
+	var options = &TxOptions{RetryBackoff: defaultTxRetryBackoff}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	gormDB := options.DB
+	if gormDB == nil {
+		db, err := DB()
+		if err != nil {
+			return err
+		}
+		gormDB = db
+	}
+
+	// 嵌套事务
+	if tx, ok := txFrom(ctx, gormDB); ok {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			return fn(ctx, tx)
+		})
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			ctx := context.WithValue(WithTx(ctx, tx), attemptKey{}, attempt)
+			return fn(ctx, tx.WithContext(ctx))
+		}, &sql.TxOptions{Isolation: options.Isolation, ReadOnly: options.ReadOnly})
+		if err == nil || options.Retry <= 0 || !driver.Retryable(err) {
+			return err
+		}
+		if attempt > options.Retry {
+			return &RetryError{Attempts: attempt, Err: err}
+		}
+
+		backoff := options.backoff(attempt)
+		logger.Warnf("[orm] >>> transaction failed, retry after %v. %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return &RetryError{Attempts: attempt, Err: err}
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// Attempt 当前事务的执行次数(从 1 开始)，不在 Tx 中时返回 0
+func Attempt(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
+
+// WithTx 将事务保存在 ctx 中，之后对同一数据库的 Tx、Conn 使用该事务。如: 测试中使用回滚的事务
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{pool: poolOf(tx)}, tx)
+}
+
+// txFrom ctx 中 gormDB 所在数据库的事务
+func txFrom(ctx context.Context, gormDB *gorm.DB) (*gorm.DB, bool) {
+	if ctx == nil || gormDB == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txKey{pool: poolOf(gormDB)}).(*gorm.DB)
+	return tx, ok
+}
+
+// poolOf 连接池。事务与开启事务的数据库返回同一连接池
+func poolOf(gormDB *gorm.DB) interface{} {
+	if db, err := gormDB.DB(); err == nil {
+		return db
+	}
+	return gormDB.Config
+}
+
+// Conn 返回 ctx 中 gormDB 所在数据库的事务，不在该数据库的事务中时返回 gormDB
+// 用于 Repository 等无需关心是否处于事务中的场景
+func Conn(ctx context.Context, gormDB *gorm.DB) *gorm.DB {
+	if tx, ok := txFrom(ctx, gormDB); ok {
+		return tx.WithContext(ctx)
+	}
+	return gormDB.WithContext(ctx)
+}
@@ -0,0 +1,164 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/database/orm/driver"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestTx(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.AutoMigrate(new(User)); err != nil {
+		t.Fatal(err)
+	}
+
+	var ctx = context.Background()
+
+	err := Tx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Create(&User{Name: "outer"}).Error; err != nil {
+			return err
+		}
+
+		// 内层回滚不影响外层
+		if err := Tx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			if err := Conn(ctx, db).Create(&User{Name: "inner"}).Error; err != nil {
+				return err
+			}
+			return errors.New("rollback")
+		}, TxDB(db)); err == nil || err.Error() != "rollback" {
+			t.Errorf("expected inner error, got %v", err)
+		}
+
+		// 事务中可读取未提交的数据
+		var count int64
+		Conn(ctx, db).Model(new(User)).Count(&count)
+		if count != 1 {
+			t.Errorf("expected 1 user in tx, got %d", count)
+		}
+		return nil
+	}, TxDB(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	db.Model(new(User)).Pluck("name", &names)
+	if len(names) != 1 || names[0] != "outer" {
+		t.Fatalf("unexpected users: %v", names)
+	}
+
+	// panic 回滚后重新抛出
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("expected panic, got %v", r)
+			}
+		}()
+		Tx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			tx.Create(&User{Name: "panic"})
+			panic("boom")
+		}, TxDB(db))
+	}()
+
+	var count int64
+	db.Model(new(User)).Where("name = ?", "panic").Count(&count)
+	if count != 0 {
+		t.Fatalf("expected rollback after panic, got %d", count)
+	}
+}
+
+func TestTxMultipleDatabases(t *testing.T) {
+	var dbs = make([]*gorm.DB, 0, 2)
+	for i := 0; i < 2; i++ {
+		db, err := Open(context.Background(), driver.SQLite, database.Address(fmt.Sprintf("file:%s/%d.db", t.TempDir(), i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer Close(db)
+
+		if err := db.AutoMigrate(new(User)); err != nil {
+			t.Fatal(err)
+		}
+		dbs = append(dbs, db)
+	}
+	a, b := dbs[0], dbs[1]
+
+	err := Tx(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		// 其他数据库不使用 a 的事务
+		if err := NewRepository[User](b).Create(ctx, &User{Name: "b"}); err != nil {
+			return err
+		}
+		if err := Tx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			if err := tx.Create(&User{Name: "b-tx"}).Error; err != nil {
+				return err
+			}
+			// b 的事务中仍可使用 a 的事务
+			return Conn(ctx, a).Create(&User{Name: "a"}).Error
+		}, TxDB(b)); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	}, TxDB(a))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var count int64
+	if a.Model(new(User)).Count(&count); count != 0 {
+		t.Fatalf("expected rollback of a, got %d users", count)
+	}
+	if b.Model(new(User)).Count(&count); count != 2 {
+		t.Fatalf("expected 2 users committed to b, got %d", count)
+	}
+}
+
+func TestTxRetry(t *testing.T) {
+	db := openTestDB(t)
+
+	deadlock := &mysqldriver.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	var attempts []int
+	err := Tx(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		attempts = append(attempts, Attempt(ctx))
+		if len(attempts) < 3 {
+			return deadlock
+		}
+		return nil
+	}, TxDB(db), TxRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 3 || attempts[2] != 3 {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+
+	// 重试次数用尽
+	var calls int
+	err = Tx(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		calls++
+		return deadlock
+	}, TxDB(db), TxRetry(2, time.Millisecond))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 || calls != 3 || !errors.Is(err, deadlock) {
+		t.Fatalf("unexpected error: %v, calls %d", err, calls)
+	}
+
+	// 不可重试的错误
+	calls = 0
+	Tx(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		calls++
+		return errors.New("failed")
+	}, TxDB(db), TxRetry(2, time.Millisecond))
+	if calls != 1 {
+		t.Fatalf("expected no retry, got %d calls", calls)
+	}
+}
@@ -0,0 +1,190 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/charlesbases/hfw/xhttp/webcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// versionName gorm plugin name
+	versionName = "hfw:version"
+	// versionExpected 保存更新前版本号的 key
+	versionExpected = "hfw:version:expected"
+)
+
+// columnVersion 版本号列
+const columnVersion = "version"
+
+var (
+	// ErrConcurrentModification 记录已被修改(或删除)，乐观锁更新失败
+	ErrConcurrentModification = errors.New("orm: concurrent modification")
+	// ErrVersionRequired 更新 Versioned 模型时未指定版本号
+	ErrVersionRequired = errors.New("orm: version required")
+)
+
+// ConcurrentModificationError 乐观锁更新失败，errors.Is(err, ErrConcurrentModification) 为 true
+type ConcurrentModificationError struct {
+	// Table 表名
+	Table string
+	// Version 更新前的版本号
+	Version interface{}
+}
+
+// Error .
+func (e *ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("%v: %s of version %v", ErrConcurrentModification, e.Table, e.Version)
+}
+
+// Unwrap .
+func (e *ConcurrentModificationError) Unwrap() error {
+	return ErrConcurrentModification
+}
+
+// Code webcode.DataConflict
+func (e *ConcurrentModificationError) Code() webcode.Code {
+	return webcode.DataConflict
+}
+
+// Versioned 实现该接口的模型更新时使用乐观锁(整型 version 列)，创建时版本号为零值则设置为 1
+// 更新条件追加 version = 更新前的版本号，并将 version 加 1，无记录更新时返回 *ConcurrentModificationError
+// 更新前的版本号依次取自:
+//   - Updates(map) 中的 "version"，如: Repository.Update(ctx, id, map[string]interface{}{"title": "x", "version": 1})
+//   - Updates、Save 的结构体中的 Version，如: Repository.Update(ctx, id, &Article{Title: "x", Version: 1})
+//   - Model 的结构体中的 Version
+//
+// 均为零值时返回 ErrVersionRequired
+type Versioned interface {
+	Versioned()
+}
+
+// version 乐观锁
+type version struct{}
+
+// Name .
+func (v *version) Name() string {
+	return versionName
+}
+
+// Initialize .
+func (v *version) Initialize(db *gorm.DB) error {
+	var errs = []error{
+		db.Callback().Create().Before("gorm:create").Register(versionName+":create", v.create),
+		db.Callback().Update().Before("gorm:update").Register(versionName+":before", v.before),
+		db.Callback().Update().After("gorm:update").Register(versionName+":after", v.after),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field .
+func (v *version) field(stmt *gorm.Statement) *schema.Field {
+	if stmt.Schema == nil {
+		return nil
+	}
+	if _, ok := reflect.New(stmt.Schema.ModelType).Interface().(Versioned); !ok {
+		return nil
+	}
+	return stmt.Schema.LookUpField(columnVersion)
+}
+
+// create 版本号为零值时设置为 1
+func (v *version) create(db *gorm.DB) {
+	stmt := db.Statement
+	field := v.field(stmt)
+	if db.Error != nil || field == nil {
+		return
+	}
+
+	set := func(rv reflect.Value) {
+		if _, zero := field.ValueOf(stmt.Context, rv); zero {
+			db.AddError(field.Set(stmt.Context, rv, 1))
+		}
+	}
+
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
+
+// before .
+func (v *version) before(db *gorm.DB) {
+	stmt := db.Statement
+	field := v.field(stmt)
+	if db.Error != nil || field == nil || internal(db) {
+		return
+	}
+
+	var expected int64
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		for key, val := range dest {
+			if f := stmt.Schema.LookUpField(key); f != nil && f.DBName == field.DBName {
+				expected = toInt64(val)
+			}
+		}
+	default:
+		if rv := reflect.Indirect(reflect.ValueOf(dest)); rv.Kind() == reflect.Struct && rv.Type() == stmt.Schema.ModelType {
+			val, _ := field.ValueOf(stmt.Context, rv)
+			expected = toInt64(val)
+		}
+	}
+	if rv := reflect.Indirect(stmt.ReflectValue); expected == 0 && rv.Kind() == reflect.Struct {
+		val, _ := field.ValueOf(stmt.Context, rv)
+		expected = toInt64(val)
+	}
+	if expected == 0 {
+		db.AddError(fmt.Errorf("%w: %s", ErrVersionRequired, stmt.Table))
+		return
+	}
+
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: expected},
+	}})
+	stmt.SetColumn(field.DBName, expected+1, true)
+	db.InstanceSet(versionExpected, expected)
+}
+
+// after .
+func (v *version) after(db *gorm.DB) {
+	expected, ok := db.InstanceGet(versionExpected)
+	if !ok || db.Error != nil || db.RowsAffected != 0 {
+		return
+	}
+
+	// 还原结构体中的版本号
+	stmt := db.Statement
+	for _, val := range []reflect.Value{stmt.ReflectValue, reflect.ValueOf(stmt.Dest)} {
+		if rv := reflect.Indirect(val); rv.Kind() == reflect.Struct && rv.CanAddr() && rv.Type() == stmt.Schema.ModelType {
+			v.field(stmt).Set(stmt.Context, rv, expected)
+		}
+	}
+	db.AddError(&ConcurrentModificationError{Table: stmt.Table, Version: expected})
+}
+
+// toInt64 .
+func toInt64(val interface{}) int64 {
+	rv := reflect.Indirect(reflect.ValueOf(val))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	default:
+		return 0
+	}
+}
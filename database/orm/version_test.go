@@ -0,0 +1,94 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/charlesbases/hfw/database"
+	"github.com/charlesbases/hfw/xhttp/webcode"
+)
+
+type Document struct {
+	ID      int64
+	Title   string
+	Version int64
+}
+
+func (*Document) Versioned() {}
+
+func TestVersion(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.AutoMigrate(new(Document)); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		ctx  = context.Background()
+		repo = NewRepository[Document](db)
+	)
+	var created = &Document{Title: "a"}
+	if err := repo.Create(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Version != 1 {
+		t.Fatalf("expected version 1, got %d", created.Version)
+	}
+
+	first, _ := repo.Get(ctx, 1)
+	second, _ := repo.Get(ctx, 1)
+
+	first.Title = "b"
+	if err := db.Save(first).Error; err != nil {
+		t.Fatal(err)
+	}
+	if first.Version != 2 {
+		t.Fatalf("expected version 2, got %d", first.Version)
+	}
+
+	second.Title = "c"
+	err := db.Save(second).Error
+	if !errors.Is(err, ErrConcurrentModification) {
+		t.Fatalf("expected ErrConcurrentModification, got %v", err)
+	}
+	var cmErr *ConcurrentModificationError
+	if !errors.As(err, &cmErr) || database.WebError(err).Code != webcode.DataConflict.Int32() || second.Version != 1 {
+		t.Fatalf("unexpected error: %v, version %d", err, second.Version)
+	}
+
+	// Updates(map) 中的 version 为更新前的版本号
+	if err := repo.Update(ctx, 1, map[string]interface{}{"title": "d", "version": 1}); !errors.Is(err, ErrConcurrentModification) {
+		t.Fatalf("expected ErrConcurrentModification, got %v", err)
+	}
+	if err := repo.Update(ctx, 1, map[string]interface{}{"title": "d", "version": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	document, _ := repo.Get(ctx, 1)
+	if document.Title != "d" || document.Version != 3 {
+		t.Fatalf("unexpected document: %+v", document)
+	}
+
+	// Updates(struct) 中的 Version 为更新前的版本号
+	stale := &Document{Title: "e", Version: 2}
+	if err := repo.Update(ctx, 1, stale); !errors.Is(err, ErrConcurrentModification) || stale.Version != 2 {
+		t.Fatalf("expected ErrConcurrentModification, got %v, version %d", err, stale.Version)
+	}
+	if err := repo.Update(ctx, 1, &Document{Title: "e", Version: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if document, _ = repo.Get(ctx, 1); document.Title != "e" || document.Version != 4 {
+		t.Fatalf("unexpected document: %+v", document)
+	}
+
+	// 未指定版本号
+	for _, fields := range []interface{}{map[string]interface{}{"title": "f"}, &Document{Title: "f"}} {
+		if err := repo.Update(ctx, 1, fields); !errors.Is(err, ErrVersionRequired) {
+			t.Fatalf("expected ErrVersionRequired, got %v", err)
+		}
+	}
+	if document, _ = repo.Get(ctx, 1); document.Title != "e" {
+		t.Fatalf("unexpected document: %+v", document)
+	}
+}
@@ -11,7 +11,7 @@ type Configuration struct {
 	// Name server name
 	Name string
 	// Port http port
-	Port string
+	Port string `default:":8080" validate:"required"`
 	// Metrics metrics
 	Metrics *Metrics
 	// JWT jwt
@@ -30,7 +30,7 @@ type Jwt struct {
 	// Enable enable jwt
 	Enable bool
 	// Secret jwt secret
-	Secret string
+	Secret string `secret:"true"`
 	// Intercept jwt 拦截器
 	Intercept *JwtIntercept
 }
@@ -54,13 +54,22 @@ type Database struct {
 	// Driver database.Driver
 	Driver database.Driver
 	// Addrs database dsn
-	Addrs []string
+	Addrs []string `validate:"required_if=Enable true" secret:"true"`
 	// Debug show sql
 	Debug bool
-	// Timeout timeout
+	// Timeout connect timeout
 	Timeout time.Duration
 }
 
+// Options database.Options of Database. Addrs 第一个地址为主库，其余为从库
+func (d *Database) Options() []database.Option {
+	var opts = []database.Option{database.Addrs(d.Addrs...), database.ShowSQL(d.Debug)}
+	if d.Timeout > 0 {
+		opts = append(opts, database.ConnectTimeout(d.Timeout))
+	}
+	return opts
+}
+
 // Broker .
 type Broker struct {
 	// Enable enable broker
@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/charlesbases/hfw/content"
 	"github.com/charlesbases/hfw/storage"
 	"github.com/charlesbases/hfw/xpath"
 	"github.com/charlesbases/logger"
@@ -127,17 +128,32 @@ func (c *client) GetObject(bucket, key string, opts ...storage.GetOption) (stora
 		logger.Debugf("[aws-s3] get(%s.%s)", bucket, key)
 	}
 
-	output, err := c.s3.GetObject(&s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket:    aws.String(bucket),
 		Key:       aws.String(key),
 		VersionId: aws.String(gopts.VersionID),
-	})
+	}
+	if len(gopts.IfNoneMatch) != 0 {
+		input.IfNoneMatch = aws.String(gopts.IfNoneMatch)
+	}
+	if !gopts.IfModifiedSince.IsZero() {
+		input.IfModifiedSince = aws.Time(gopts.IfModifiedSince)
+	}
+
+	output, err := c.s3.GetObject(input)
 	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotModified {
+			return nil, storage.ErrNotModified
+		}
 		logger.Errorf("[aws-s3] get(%s.%s) failed. %s", bucket, key, err.Error())
 		return nil, err
 	}
 
-	return storage.ReadCloser(output.Body, aws.Int64Value(output.ContentLength), aws.TimeValue(output.LastModified)), nil
+	contentType, found := content.Parse(aws.StringValue(output.ContentType))
+	if !found {
+		contentType = content.Stream
+	}
+	return storage.ReadCloser(output.Body, aws.Int64Value(output.ContentLength), aws.TimeValue(output.LastModified), storage.ContentType(contentType), storage.ETag(aws.StringValue(output.ETag))), nil
 }
 
 func (c *client) DelObject(bucket, key string, opts ...storage.DelOption) error {
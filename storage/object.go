@@ -22,6 +22,7 @@ type Object interface {
 	Error() error
 	DeferFunc() func()
 
+	ETag() string
 	Modify() time.Time
 	ContentType() content.Type
 	ContentLength() int64
@@ -38,6 +39,7 @@ type object struct {
 	rs io.ReadSeeker
 	rc io.ReadCloser
 
+	etag          string
 	modify        time.Time
 	contentType   content.Type
 	contentLength int64
@@ -62,6 +64,20 @@ func Proto() objectOption {
 	}
 }
 
+// ContentType .
+func ContentType(ct content.Type) objectOption {
+	return func(o *object) {
+		o.contentType = ct
+	}
+}
+
+// ETag .
+func ETag(etag string) objectOption {
+	return func(o *object) {
+		o.etag = etag
+	}
+}
+
 func (o *object) Error() error {
 	return o.err
 }
@@ -74,6 +90,10 @@ func (o *object) DeferFunc() func() {
 	return o.deferFunc
 }
 
+func (o *object) ETag() string {
+	return o.etag
+}
+
 func (o *object) Modify() time.Time {
 	return o.modify
 }
@@ -213,13 +233,17 @@ func ReadSeeker(rs io.ReadSeeker, contentLength int64) Object {
 }
 
 // ReadCloser .
-func ReadCloser(rc io.ReadCloser, contentLength int64, modify time.Time) Object {
-	return &object{
+func ReadCloser(rc io.ReadCloser, contentLength int64, modify time.Time, opts ...objectOption) Object {
+	o := &object{
 		rc:            rc,
 		modify:        modify,
 		contentLength: contentLength,
 		deferFunc:     func() { rc.Close() },
 	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
 }
 
 type Objects interface {
@@ -27,6 +27,8 @@ var (
 var (
 	// ErrObjectDecodingIncorrect incorrect object type
 	ErrObjectDecodingIncorrect = errors.New("object decoding failed. incorrect object type.")
+	// ErrNotModified object not modified. returned by GetObject with GetIfNoneMatch or GetIfModifiedSince
+	ErrNotModified = errors.New("object not modified")
 )
 
 type Storage interface {
@@ -144,6 +146,10 @@ type GetOptions struct {
 	Context context.Context
 	// VersionID object version
 	VersionID string
+	// IfNoneMatch return ErrNotModified if the ETag of object matches
+	IfNoneMatch string
+	// IfModifiedSince return ErrNotModified if the object has not been modified since
+	IfModifiedSince time.Time
 	// Debug show logger
 	Debug bool
 }
@@ -176,6 +182,20 @@ func GetVersion(version string) GetOption {
 	}
 }
 
+// GetIfNoneMatch .
+func GetIfNoneMatch(etag string) GetOption {
+	return func(o *GetOptions) {
+		o.IfNoneMatch = etag
+	}
+}
+
+// GetIfModifiedSince .
+func GetIfModifiedSince(t time.Time) GetOption {
+	return func(o *GetOptions) {
+		o.IfModifiedSince = t
+	}
+}
+
 // GetDisableDebug .
 func GetDisableDebug() GetOption {
 	return func(o *GetOptions) {
@@ -16,5 +16,8 @@ var (
 	ServiceTimeout     = add(5002, "服务调用超时")
 	DatabaseErr        = add(5100, "数据库操作失败")
 	DataNotFound       = add(5101, "未查询到数据")
+	DataConflict       = add(5102, "数据已被修改")
+	DataExists         = add(5103, "数据已存在")
+	DataReferenced     = add(5104, "关联数据错误")
 	InternalErr        = add(5200, "内部请求错误")
 )
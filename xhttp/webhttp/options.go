@@ -32,6 +32,8 @@ type options struct {
 	header map[string]string
 	// marshaler .
 	marshaler codec.Marshaler
+	// raw 不解析 Response，直接返回响应内容
+	raw bool
 }
 
 // param .
@@ -58,7 +60,10 @@ func defaultClient() *http.Client {
 
 // newOptions .
 func newOptions(opts ...option) *options {
-	var options = &options{client: defaultClient(), header: defaultHeader, marshaler: defaultMarshaler}
+	var options = &options{client: defaultClient(), header: make(map[string]string, len(defaultHeader)), marshaler: defaultMarshaler}
+	for key, val := range defaultHeader {
+		options.header[key] = val
+	}
 	for _, o := range opts {
 		o(options)
 	}
@@ -73,7 +78,9 @@ func newOptions(opts ...option) *options {
 // Header .
 func Header(key, val string) option {
 	return func(o *options) {
-		o.header[key] = val
+		if len(key) != 0 {
+			o.header[key] = val
+		}
 	}
 }
 
@@ -97,6 +104,9 @@ func ContentType(ct content.Type) option {
 type data struct {
 	opts *options
 	data []byte
+
+	header      http.Header
+	notModified bool
 }
 
 // Bytes .
@@ -104,6 +114,23 @@ func (d *data) Bytes() []byte {
 	return d.data
 }
 
+// Header response header
+func (d *data) Header() http.Header {
+	return d.header
+}
+
+// NotModified 304 Not Modified，用于 If-None-Match、If-Modified-Since 请求
+func (d *data) NotModified() bool {
+	return d.notModified
+}
+
+// Raw 为 true 时不解析 {"code", "data"} 格式的 Response，直接返回响应内容
+func Raw(b bool) option {
+	return func(o *options) {
+		o.raw = b
+	}
+}
+
 // Unmarshal .
 func (d *data) Unmarshal(pointer interface{}) error {
 	return d.opts.marshaler.Unmarshal(d.data, pointer)
@@ -133,6 +160,10 @@ func (opts *options) do(req *http.Request) (*data, error) {
 
 	switch rsp.StatusCode {
 	case http.StatusOK:
+		if opts.raw {
+			return &data{opts: opts, data: body, header: rsp.Header}, nil
+		}
+
 		var response = new(Response)
 		if err := opts.marshaler.Unmarshal(body, response); err != nil {
 			logger.Errorf("%s | %s | %d | %s.Unmarshal() error: %v", req.Method, req.URL, http.StatusOK, opts.marshaler.Type(), err)
@@ -143,7 +174,9 @@ func (opts *options) do(req *http.Request) (*data, error) {
 			logger.Errorf(`%s | %s | %d | {"code": %d, "message": "%s"}`, req.Method, req.URL, http.StatusOK, response.Code, response.Message)
 			return nil, webcode.InternalErr
 		}
-		return &data{opts: opts, data: response.Data}, nil
+		return &data{opts: opts, data: response.Data, header: rsp.Header}, nil
+	case http.StatusNotModified:
+		return &data{opts: opts, header: rsp.Header, notModified: true}, nil
 	default:
 		logger.Errorf("%s | %s | %d | %s", req.Method, req.URL, rsp.StatusCode, string(body))
 		return nil, webcode.InternalErr